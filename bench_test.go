@@ -0,0 +1,53 @@
+package gomcli
+
+import (
+	"bytes"
+	"testing"
+)
+
+const benchInput = `deploy --region eu-west-1 "my service" 'v1.2.3'; status all; show config verbose`
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(benchInput); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func newBenchCLI() *GomCLI {
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	for _, name := range []string{"status", "show config", "show users", "start", "stop", "deploy"} {
+		c.AddCommand(Command{Name: name, Function: func(args ...string) {}})
+	}
+	c.AddCommand(Command{Name: "connect", Function: func(host string, port int) {}})
+	return c
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	c := newBenchCLI()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.processInput("connect example.com 22")
+	}
+}
+
+func BenchmarkDispatchSubcommand(b *testing.B) {
+	c := newBenchCLI()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.processInput("show config verbose")
+	}
+}
+
+func BenchmarkDispatchNotFound(b *testing.B) {
+	c := newBenchCLI()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.processInput("unknown command here")
+	}
+}
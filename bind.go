@@ -0,0 +1,198 @@
+package gomcli
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// structField describes a field of a struct bound to CLI arguments, as
+// configured by its gomcli tag.
+type structField struct {
+	index    int
+	name     string
+	required bool
+	def      string
+	hasDef   bool
+}
+
+// isStructBinding reports whether the parameters of a Function, besides the
+// injected ones, are a single pointer to struct, whose fields are to be bound
+// from the arguments.
+func isStructBinding(argTypes []reflect.Type) bool {
+	return len(argTypes) == 1 && argTypes[0].Kind() == reflect.Ptr &&
+		argTypes[0].Elem().Kind() == reflect.Struct
+}
+
+// structFields parses the gomcli tags of a struct type. Each exported field is
+// bound to an argument named after the first element of the tag, or the
+// lowercase field name if empty, and can be marked as "required" or given a
+// "default=value". Fields tagged "-" are ignored.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("gomcli")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		field := structField{index: i, name: opts[0]}
+		if field.name == "" {
+			field.name = strings.ToLower(f.Name)
+		}
+		for _, opt := range opts[1:] {
+			switch {
+			case opt == "required":
+				field.required = true
+			case strings.HasPrefix(opt, "default="):
+				field.def = strings.TrimPrefix(opt, "default=")
+				field.hasDef = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// bindStruct returns a pointer to a new value of the struct type pointed to by
+// t, with its fields set from args. Arguments in the form name=value set the
+// field with that name, and the rest set the remaining fields in order.
+func (c *Command) bindStruct(s *Session, t reflect.Type, args []string) (reflect.Value, error) {
+	ptr := reflect.New(t.Elem())
+	v := ptr.Elem()
+	fields := structFields(t.Elem())
+	set := make([]bool, len(fields))
+
+	assign := func(i int, argIndex int, token string, value string) error {
+		f := fields[i]
+		fieldValue, err := s.cli.convertArg(v.Field(f.index).Type(), value)
+		if err != nil {
+			return &ArgumentError{
+				Cmd:   c.Name,
+				Index: argIndex,
+				Token: token,
+				Want:  v.Field(f.index).Type(),
+				Err:   err,
+			}
+		}
+		v.Field(f.index).Set(fieldValue)
+		set[i] = true
+		return nil
+	}
+
+	next := 0
+	for argIndex, arg := range args {
+		if eq := strings.IndexByte(arg, '='); eq > 0 {
+			if i := fieldByName(fields, arg[:eq]); i >= 0 {
+				if err := assign(i, argIndex, arg, arg[eq+1:]); err != nil {
+					return ptr, err
+				}
+				continue
+			}
+		}
+
+		for next < len(fields) && set[next] {
+			next++
+		}
+		if next == len(fields) {
+			return ptr, &ArgumentError{
+				Cmd:   c.Name,
+				Index: argIndex,
+				Token: arg,
+				Err:   ErrCmdInvalidArgs,
+			}
+		}
+		if err := assign(next, argIndex, arg, arg); err != nil {
+			return ptr, err
+		}
+	}
+
+	for i, f := range fields {
+		if set[i] {
+			continue
+		}
+		if f.hasDef {
+			if err := assign(i, len(args), f.name, f.def); err != nil {
+				return ptr, err
+			}
+		} else if f.required {
+			return ptr, &ArgumentError{
+				Cmd:   c.Name,
+				Index: len(args),
+				Name:  f.name,
+				Want:  v.Field(f.index).Type(),
+				Err:   ErrCmdMissingArgs,
+			}
+		}
+	}
+
+	return ptr, nil
+}
+
+func fieldByName(fields []structField, name string) int {
+	for i, f := range fields {
+		if f.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Bind registers a Command for each exported method of obj, so that service
+// objects can be exposed with a single call. Command names are derived from
+// method names in kebab-case, e.g. ListUsers becomes "list-users", and the
+// arguments from the method signatures. If obj has a method named after another
+// one with the Help suffix, taking no arguments and returning a string, e.g.
+// ListUsersHelp, its result is used as Help instead of registering it.
+func (c *GomCLI) Bind(obj interface{}) {
+	v := reflect.ValueOf(obj)
+	t := v.Type()
+
+	helps := make(map[string]string)
+	helpMethods := make(map[string]bool)
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		base := strings.TrimSuffix(m.Name, "Help")
+		if _, ok := t.MethodByName(base); !ok || base == m.Name || !isHelpMethod(m.Type) {
+			continue
+		}
+		helps[base] = v.Method(i).Call(nil)[0].String()
+		helpMethods[m.Name] = true
+	}
+
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if helpMethods[m.Name] {
+			continue
+		}
+		c.addCommand(Command{
+			Name:     kebabCase(m.Name),
+			Function: v.Method(i).Interface(),
+			Help:     helps[m.Name],
+		})
+	}
+}
+
+// isHelpMethod reports whether the type of a method, including its receiver,
+// is func() string.
+func isHelpMethod(t reflect.Type) bool {
+	return t.NumIn() == 1 && t.NumOut() == 1 && t.Out(0).Kind() == reflect.String
+}
+
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package gomcli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBindMissingRequiredField(t *testing.T) {
+	type opts struct {
+		Host string `gomcli:"host,required"`
+		Port int    `gomcli:"port,default=22"`
+	}
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	var got error
+	c.AddCommand(Command{
+		Name:     "connect",
+		Function: func(o *opts) {},
+		ErrHandler: func(_ *Command, _ []string, err error) error {
+			got = err
+			return nil
+		},
+	})
+
+	c.processInput("connect")
+	var argErr *ArgumentError
+	if !errors.As(got, &argErr) {
+		t.Fatalf("error = %v, want an *ArgumentError", got)
+	}
+	if argErr.Token != "" || argErr.Name != "host" || !errors.Is(got, ErrCmdMissingArgs) {
+		t.Errorf("error = %#v", argErr)
+	}
+	if want := `Missing arguments: host (argument 0) of "connect"`; got.Error() != want {
+		t.Errorf("Error() = %q, want %q", got.Error(), want)
+	}
+}
@@ -0,0 +1,131 @@
+package gomcli
+
+import "flag"
+
+// ArgOption configures an Arg declared with CommandBuilder.Arg.
+type ArgOption func(*Arg)
+
+// WithChoices restricts the values accepted for an Arg, which are also offered
+// as completions.
+func WithChoices(choices ...string) ArgOption {
+	return func(a *Arg) {
+		a.Choices = choices
+	}
+}
+
+// CommandBuilder builds a Command through chained calls, as a more readable
+// alternative to struct literals for larger declarations:
+//
+//	cli.AddCommand(gomcli.NewCommand("deploy").
+//		Help("Deploy the application").
+//		Arg("env", gomcli.WithChoices("staging", "production")).
+//		Handler(deploy))
+type CommandBuilder struct {
+	cmd Command
+}
+
+// NewCommand returns a CommandBuilder for a Command with the given name.
+func NewCommand(name string) *CommandBuilder {
+	return &CommandBuilder{cmd: Command{Name: name}}
+}
+
+// Help sets the Help of the Command.
+func (b *CommandBuilder) Help(help string) *CommandBuilder {
+	b.cmd.Help = help
+	return b
+}
+
+// Usage sets the Usage of the Command.
+func (b *CommandBuilder) Usage(usage string) *CommandBuilder {
+	b.cmd.Usage = usage
+	return b
+}
+
+// Arg declares the next positional argument of the Command.
+func (b *CommandBuilder) Arg(name string, opts ...ArgOption) *CommandBuilder {
+	arg := Arg{Name: name}
+	for _, opt := range opts {
+		opt(&arg)
+	}
+	b.cmd.Args = append(b.cmd.Args, arg)
+	return b
+}
+
+// Flags sets the FlagSet used to parse the options of the Command.
+func (b *CommandBuilder) Flags(flags *flag.FlagSet) *CommandBuilder {
+	b.cmd.Flags = flags
+	return b
+}
+
+// ErrHandler sets the ErrHandler of the Command.
+func (b *CommandBuilder) ErrHandler(handler ErrHandler) *CommandBuilder {
+	b.cmd.ErrHandler = handler
+	return b
+}
+
+// Completer sets the Completer of the Command.
+func (b *CommandBuilder) Completer(completer Completer) *CommandBuilder {
+	b.cmd.Completer = completer
+	return b
+}
+
+// CompleterWithDesc sets the CompleterWithDesc of the Command.
+func (b *CommandBuilder) CompleterWithDesc(completer CompleterWithDesc) *CommandBuilder {
+	b.cmd.CompleterWithDesc = completer
+	return b
+}
+
+// ArgCompleters sets the Completers of the arguments of the Command, by
+// position.
+func (b *CommandBuilder) ArgCompleters(completers ...Completer) *CommandBuilder {
+	b.cmd.ArgCompleters = completers
+	return b
+}
+
+// Redirect marks the Command as deprecated in favour of the named one.
+func (b *CommandBuilder) Redirect(name string) *CommandBuilder {
+	b.cmd.Redirect = name
+	return b
+}
+
+// SecretArgs sets the positions of the arguments masked in the history.
+func (b *CommandBuilder) SecretArgs(positions ...int) *CommandBuilder {
+	b.cmd.SecretArgs = positions
+	return b
+}
+
+// NoHistory keeps the lines invoking the Command out of the history.
+func (b *CommandBuilder) NoHistory() *CommandBuilder {
+	b.cmd.NoHistory = true
+	return b
+}
+
+// Streaming marks the Command as streaming.
+func (b *CommandBuilder) Streaming() *CommandBuilder {
+	b.cmd.Streaming = true
+	return b
+}
+
+// FuzzyComplete opts the arguments of the Command in for fuzzy completion.
+func (b *CommandBuilder) FuzzyComplete() *CommandBuilder {
+	b.cmd.FuzzyComplete = true
+	return b
+}
+
+// Entities sets the kind of the published entities completing the arguments
+// of the Command.
+func (b *CommandBuilder) Entities(kind string) *CommandBuilder {
+	b.cmd.Entities = kind
+	return b
+}
+
+// Handler sets the Function of the Command, and returns the built Command.
+func (b *CommandBuilder) Handler(function interface{}) Command {
+	b.cmd.Function = function
+	return b.cmd
+}
+
+// Build returns the built Command.
+func (b *CommandBuilder) Build() Command {
+	return b.cmd
+}
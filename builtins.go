@@ -0,0 +1,34 @@
+package gomcli
+
+// EnableBuiltins registers the built-in "exit" and "quit" Commands, which stop
+// the CLI as with Stop, except that "exit" leaves the active context, if any,
+// as with PopContext. Names already registered are left untouched, and any of
+// them can be replaced afterwards with AddCommand, or removed with
+// RemoveCommand.
+func (c *GomCLI) EnableBuiltins() {
+	builtins := []Command{
+		{Name: "exit", Help: "Leave the current context, or exit the CLI", builtin: exitBuiltin},
+		{Name: "quit", Help: "Exit the CLI", builtin: quitBuiltin},
+	}
+	for _, cmd := range builtins {
+		if _, ok := c.commands[cmd.Name]; ok {
+			continue
+		}
+		cmd.Usage = cmd.Name
+		c.AddCommand(cmd)
+	}
+}
+
+func exitBuiltin(s *Session, args []string) error {
+	if len(s.cli.contexts) > 0 {
+		s.cli.PopContext()
+		return nil
+	}
+	s.cli.Stop()
+	return nil
+}
+
+func quitBuiltin(s *Session, args []string) error {
+	s.cli.Stop()
+	return nil
+}
@@ -0,0 +1,31 @@
+package gomcli
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// EnableClear registers the built-in "clear" and "cls" Commands, which clear
+// the screen, leaving the next prompt at the top.
+func (c *GomCLI) EnableClear() {
+	for _, name := range []string{"clear", "cls"} {
+		c.AddCommand(Command{
+			Name:    name,
+			Help:    "Clear the screen",
+			Usage:   name,
+			builtin: clearBuiltin,
+		})
+	}
+}
+
+func clearBuiltin(s *Session, args []string) error {
+	if runtime.GOOS == "windows" && s.IsTerminal() && os.Getenv("WT_SESSION") == "" {
+		// The legacy console does not necessarily process escape sequences.
+		cmd := exec.Command("cmd", "/c", "cls")
+		cmd.Stdout = os.Stdout
+		return cmd.Run()
+	}
+	s.Print("\x1b[H\x1b[2J")
+	return nil
+}
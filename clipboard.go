@@ -0,0 +1,97 @@
+package gomcli
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// ErrClipboardUnsupported is returned from the clipboard helpers when no
+// clipboard mechanism is available on the current platform.
+var ErrClipboardUnsupported = errors.New("Clipboard not supported")
+
+// ErrNoLastOutput is returned by Session.CopyLastOutput when no output of a
+// Command has been kept.
+var ErrNoLastOutput = errors.New("No output to copy")
+
+// CopyToClipboard copies text to the system clipboard.
+func CopyToClipboard(text string) error {
+	cmd := clipboardCopyCmd()
+	if cmd == nil {
+		return ErrClipboardUnsupported
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// CopyLastOutput copies the output of the last Command, as kept in the session
+// variable LAST_OUTPUT if enabled with SetCaptureOutput, to the system
+// clipboard.
+func (s *Session) CopyLastOutput() error {
+	out, ok := s.Var("LAST_OUTPUT")
+	if !ok {
+		return ErrNoLastOutput
+	}
+	return CopyToClipboard(out)
+}
+
+// EnableCopy registers a built-in "copy" Command, which copies the output of
+// the last Command to the system clipboard, and enables SetCaptureOutput for
+// it to be kept.
+func (c *GomCLI) EnableCopy() {
+	c.SetCaptureOutput(true)
+	c.AddCommand(Command{
+		Name:        "copy",
+		Help:        "Copy the output of the last command to the clipboard",
+		Usage:       "copy",
+		builtin:     copyBuiltin,
+		keepsOutput: true,
+	})
+}
+
+func copyBuiltin(s *Session, args []string) error {
+	if len(args) != 0 {
+		s.Println("Usage: copy")
+		return nil
+	}
+	if err := s.CopyLastOutput(); err != nil {
+		s.Println(err)
+	}
+	return nil
+}
+
+// PasteFromClipboard returns the current contents of the system clipboard.
+func PasteFromClipboard() (string, error) {
+	cmd := clipboardPasteCmd()
+	if cmd == nil {
+		return "", ErrClipboardUnsupported
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// PasteToPrompt places the first line of the system clipboard contents into the
+// input buffer of the next prompt, so that it can be edited before submitting.
+func (c *GomCLI) PasteToPrompt() error {
+	text, err := PasteFromClipboard()
+	if err != nil {
+		return err
+	}
+	if i := strings.IndexAny(text, "\r\n"); i >= 0 {
+		text = text[:i]
+	}
+	c.pending = text
+	return nil
+}
+
+func lookPathCmd(candidates ...[]string) *exec.Cmd {
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return exec.Command(args[0], args[1:]...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build darwin
+// +build darwin
+
+package gomcli
+
+import "os/exec"
+
+func clipboardCopyCmd() *exec.Cmd {
+	return lookPathCmd([]string{"pbcopy"})
+}
+
+func clipboardPasteCmd() *exec.Cmd {
+	return lookPathCmd([]string{"pbpaste"})
+}
@@ -0,0 +1,14 @@
+//go:build !darwin && !windows && !linux && !freebsd && !netbsd && !openbsd
+// +build !darwin,!windows,!linux,!freebsd,!netbsd,!openbsd
+
+package gomcli
+
+import "os/exec"
+
+func clipboardCopyCmd() *exec.Cmd {
+	return nil
+}
+
+func clipboardPasteCmd() *exec.Cmd {
+	return nil
+}
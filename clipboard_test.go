@@ -0,0 +1,42 @@
+package gomcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCopyBuiltin(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fake clipboard needs wl-copy lookup")
+	}
+	dir := t.TempDir()
+	copied := filepath.Join(dir, "copied")
+	script := "#!/bin/sh\ncat > " + copied + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "wl-copy"), []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var out bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.EnableCopy()
+	c.AddCommand(Command{Name: "show", Function: func(s *Session) {
+		s.Println("hello")
+	}})
+
+	c.processInput("copy")
+	if got := out.String(); got != ErrNoLastOutput.Error()+"\n" {
+		t.Errorf("copy without output printed %q", got)
+	}
+
+	for _, line := range []string{"show", "copy", "copy"} {
+		c.processInput(line)
+	}
+	data, err := os.ReadFile(copied)
+	if err != nil || string(data) != "hello" {
+		t.Errorf("clipboard = %q, %v, want %q", data, err, "hello")
+	}
+}
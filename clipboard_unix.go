@@ -0,0 +1,22 @@
+//go:build linux || freebsd || netbsd || openbsd
+// +build linux freebsd netbsd openbsd
+
+package gomcli
+
+import "os/exec"
+
+func clipboardCopyCmd() *exec.Cmd {
+	return lookPathCmd(
+		[]string{"wl-copy"},
+		[]string{"xclip", "-selection", "clipboard"},
+		[]string{"xsel", "--clipboard", "--input"},
+	)
+}
+
+func clipboardPasteCmd() *exec.Cmd {
+	return lookPathCmd(
+		[]string{"wl-paste", "--no-newline"},
+		[]string{"xclip", "-selection", "clipboard", "-o"},
+		[]string{"xsel", "--clipboard", "--output"},
+	)
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package gomcli
+
+import "os/exec"
+
+func clipboardCopyCmd() *exec.Cmd {
+	return lookPathCmd([]string{"clip"})
+}
+
+func clipboardPasteCmd() *exec.Cmd {
+	return lookPathCmd([]string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"})
+}
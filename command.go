@@ -1,19 +1,24 @@
 package gomcli
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
-// ErrCmdMissingArgs is passed to ErrHandler when the number of arguments
-// provided via CLI for a Command is less than the number of arguments for
-// its defined Function.
+// ErrCmdMissingArgs is passed to ErrHandler, wrapped in an *ArgumentError,
+// when the number of arguments provided via CLI for a Command is less than the
+// number of arguments for its defined Function.
 var ErrCmdMissingArgs = errors.New("Missing arguments")
 
-// ErrCmdInvalidArgs is passed to ErrHandler when the arguments provided via
-// CLI for a Command cannot be converted to the argument types for its defined
-// Function.
+// ErrCmdInvalidArgs is passed to ErrHandler, wrapped in an *ArgumentError,
+// when the arguments provided via CLI for a Command cannot be converted to the
+// argument types for its defined Function.
 var ErrCmdInvalidArgs = errors.New("Invalid arguments")
 
 // ErrCmdArgOverflow is passed to ErrHandler when the value provided via CLI
@@ -24,34 +29,246 @@ var ErrCmdArgOverflow = errors.New("Value too big")
 // argument is not supported.
 var ErrCmdArgUnsupportedKind = errors.New("Unsupported Kind")
 
+// ErrCmdPanicked is wrapped by the PanicError passed to the PanicHandler, or to
+// ErrHandler if none is set, when a Command's Function panics.
+var ErrCmdPanicked = errors.New("Command panicked")
+
+// PanicError holds the value a Command's Function panicked with, along with the
+// stack trace at the time of the panic.
+type PanicError struct {
+	Cmd   string
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %q: %v", ErrCmdPanicked, e.Cmd, e.Value)
+}
+
+// Unwrap returns ErrCmdPanicked.
+func (e *PanicError) Unwrap() error {
+	return ErrCmdPanicked
+}
+
+// ArgumentError is passed to ErrHandler when the arguments provided via CLI for
+// a Command cannot be used to call its Function. Index is the position of the
+// offending argument, Name its name if known, e.g. the field of a bound struct,
+// Token the input provided for it (empty if missing) and Want the type expected
+// by the Function (nil if no argument was expected). Err holds the underlying
+// cause, and errors.Is reports ErrCmdInvalidArgs for any cause other than
+// ErrCmdMissingArgs.
+type ArgumentError struct {
+	Cmd   string
+	Index int
+	Name  string
+	Token string
+	Want  reflect.Type
+	Err   error
+}
+
+func (e *ArgumentError) Error() string {
+	if e.Token == "" && e.Name != "" {
+		return fmt.Sprintf("%v: %v (argument %d) of %q", e.Err, e.Name, e.Index, e.Cmd)
+	}
+	if e.Token == "" {
+		return fmt.Sprintf("%v: argument %d of %q", e.Err, e.Index, e.Cmd)
+	}
+	return fmt.Sprintf("%v: argument %d (%q) of %q", e.Err, e.Index, e.Token, e.Cmd)
+}
+
+// Unwrap returns the underlying cause of the ArgumentError.
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the ArgumentError matches ErrCmdInvalidArgs.
+func (e *ArgumentError) Is(target error) bool {
+	return target == ErrCmdInvalidArgs && e.Err != ErrCmdMissingArgs
+}
+
 // Completer takes a string and returns a list of completion candidates. It can be
 // set for a given Command to indicate gomcli how to complete subcommands.
 type Completer func(string) []string
 
+// Completion is a completion candidate along with a short Description of it,
+// shown next to it when the candidates are listed.
+type Completion struct {
+	Text        string
+	Description string
+}
+
+// CompleterWithDesc is like Completer, but returns the candidates along with
+// their descriptions.
+type CompleterWithDesc func(string) []Completion
+
+// Arg describes a positional argument of a Command. Name is shown in the usage,
+// and if Choices is not empty, the argument must be one of them.
+type Arg struct {
+	Name    string
+	Choices []string
+}
+
+// Converter takes an argument provided via CLI and returns the value to be passed
+// to a Function parameter of the type it is registered for with
+// GomCLI.RegisterConverter.
+type Converter func(string) (interface{}, error)
+
 // ErrHandler takes a Command, an input string and a given error when parsing
 // said Command, and returns an error to be propagated to GomCLI.Start, if needed.
 // Otherwise, this is the point where the errors from CLI input for a Command
 // are to be gracefully handled.
 type ErrHandler func(*Command, []string, error) error
 
-// Command represents a function that can be executed via the CLI. Name defines the
-// string that needs to be provided via the CLI to execute the Function. ErrHandler
-// allows to handle errors when converting the input to arguments for the Function.
-// Completer allows to provide completions for subcommands.
+// Command represents a function that can be executed via the CLI. Name defines
+// the string that needs to be provided via the CLI to execute the Function.
+// ErrHandler allows to handle errors when converting the input to arguments
+// for the Function.
+//
+// Completer allows to provide completions for subcommands, and
+// CompleterWithDesc to provide them along with descriptions, shown by the
+// partial help; Completer takes precedence if both are set. ArgCompleters, if
+// set, completes instead each argument with the Completer at its position,
+// none for nil ones or past the end, e.g. hosts for the first argument of
+// "connect <host> <port>" and nothing for the second one. Entities, if set and
+// Completer is not, completes the arguments with the entities of that kind
+// published with Session.Publish, e.g. "hosts". FuzzyComplete opts the
+// arguments in for fuzzy completion, see GomCLI.SetFuzzyCompletion, matching
+// what the Completer returns for an empty string.
+//
+// Redirect marks the Command as deprecated in favour of the Command with the
+// given name, to which invocations are transparently dispatched. SecretArgs
+// lists the positions of arguments to be masked in the history, while
+// NoHistory keeps the lines invoking the Command out of the history
+// altogether.
+//
+// Help and Usage are shown by the built-in help Command. Args optionally
+// describes the positional arguments. Flags, if set, is used to parse options
+// given before the positional arguments, whose values are available through
+// the variables bound to the FlagSet; it should be created with
+// flag.ContinueOnError. Streaming marks a Command that keeps producing output
+// until interrupted: while it runs, Ctrl-C cancels Session.Context instead of
+// terminating the program.
+//
+// Function can declare a *Session and a context.Context as its first
+// parameters, which are provided by gomcli. The context is cancelled when
+// Ctrl-C is pressed during the execution or the CLI is closed. The rest of the
+// parameters are converted from the arguments in order or, if the only one is a
+// pointer to struct, its fields are set from the arguments following their
+// gomcli tags, e.g. `gomcli:"host,required"` or `gomcli:"port,default=22"`. A
+// final variadic or slice parameter receives all the remaining arguments.
+//
+// If Function returns an error, it is passed to ErrHandler in the same way as
+// argument errors, and returned from Start when not handled if
+// GomCLI.SetExitOnCmdError is enabled.
 type Command struct {
-	Name       string
-	Function   interface{}
-	ErrHandler ErrHandler
-	Completer  Completer
+	Name              string
+	Function          interface{}
+	ErrHandler        ErrHandler
+	Completer         Completer
+	CompleterWithDesc CompleterWithDesc
+	ArgCompleters     []Completer
+	Redirect          string
+	SecretArgs        []int
+	NoHistory         bool
+	Help              string
+	Usage             string
+	Args              []Arg
+	Flags             *flag.FlagSet
+	Streaming         bool
+	FuzzyComplete     bool
+	Entities          string
+
+	// builtin, if set, is called instead of Function with the raw arguments.
+	builtin func(*Session, []string) error
+
+	// typed, if set, is called instead of Function with the raw arguments,
+	// and the error it returns is handled as returned by Function. signature
+	// holds the types of the arguments it expects, for the usage.
+	typed     func(*Session, []string) error
+	signature []reflect.Type
+
+	// grammar, if set, is the Grammar the Command was created from.
+	grammar *Grammar
+
+	// files marks the built-in Commands that read or write files.
+	files bool
+
+	// assigns marks the built-in Commands that set environment variables.
+	assigns bool
+
+	// keepsOutput marks the built-in Commands whose output does not replace
+	// LAST_OUTPUT, see SetCaptureOutput.
+	keepsOutput bool
+}
+
+// completions returns the completion candidates of the Command for line, with
+// their descriptions if it has a CompleterWithDesc.
+func (c *Command) completions(line string) []Completion {
+	if c.Completer == nil && c.CompleterWithDesc != nil {
+		return c.CompleterWithDesc(line)
+	}
+	words := c.complete(line)
+	res := make([]Completion, len(words))
+	for i, w := range words {
+		res[i] = Completion{Text: w}
+	}
+	return res
+}
+
+// completer returns the Completer of the Command, derived from its
+// CompleterWithDesc if it has none.
+func (c *Command) completer() Completer {
+	if c.Completer != nil || c.CompleterWithDesc == nil {
+		return c.Completer
+	}
+	return func(line string) []string {
+		comps := c.CompleterWithDesc(line)
+		res := make([]string, len(comps))
+		for i, comp := range comps {
+			res[i] = comp.Text
+		}
+		return res
+	}
 }
 
 func (c *Command) complete(line string) []string {
-	if c.Completer != nil {
-		return c.Completer(line)
+	if completer := c.completer(); completer != nil {
+		return completer(line)
+	}
+	if len(c.Args) > 0 {
+		var res []string
+		for _, choice := range c.Args[0].Choices {
+			if strings.HasPrefix(choice, line) {
+				res = append(res, choice)
+			}
+		}
+		return res
 	}
 	return []string{}
 }
 
+// checkChoices verifies that args are among the Choices of their Arg.
+func (c *Command) checkChoices(args []string) error {
+	for i, arg := range c.Args {
+		if i >= len(args) || len(arg.Choices) == 0 {
+			continue
+		}
+		valid := false
+		for _, choice := range arg.Choices {
+			valid = valid || choice == args[i]
+		}
+		if !valid {
+			return &ArgumentError{
+				Cmd:   c.Name,
+				Index: i,
+				Token: args[i],
+				Err:   ErrCmdInvalidArgs,
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Command) handleErr(err error, args []string) error {
 	if c.ErrHandler == nil {
 		return err
@@ -63,7 +280,54 @@ func (c *Command) handleErr(err error, args []string) error {
 	return nil
 }
 
-func (c *Command) execute(args ...string) error {
+var sessionType = reflect.TypeOf((*Session)(nil))
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// isInjected reports whether a parameter of type t of a Function is provided
+// by gomcli rather than from the CLI arguments.
+func isInjected(t reflect.Type) bool {
+	return t == sessionType || t == contextType
+}
+
+// wantsContext reports whether the Function receives a context.Context.
+func (c *Command) wantsContext() bool {
+	t := reflect.TypeOf(c.Function)
+	if t == nil || t.Kind() != reflect.Func {
+		return false
+	}
+	for i := 0; i < t.NumIn() && isInjected(t.In(i)); i++ {
+		if t.In(i) == contextType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Command) execute(s *Session, args ...string) error {
+	if c.builtin != nil {
+		return c.builtin(s, args)
+	}
+
+	if c.Flags != nil {
+		var ok bool
+		var err error
+		if args, ok, err = c.parseFlags(args); !ok {
+			return err
+		}
+	}
+
+	if err := c.checkChoices(args); err != nil {
+		return c.handleErr(err, args)
+	}
+
+	if c.typed != nil {
+		if err := c.typed(s, args); err != nil {
+			return c.handleErr(err, args)
+		}
+		return nil
+	}
+
 	if c.Function == nil {
 		return nil
 	}
@@ -74,37 +338,215 @@ func (c *Command) execute(args ...string) error {
 	}
 
 	t := v.Type()
-	ni := t.NumIn()
+
+	argTypes := make([]reflect.Type, t.NumIn())
+	for i := range argTypes {
+		argTypes[i] = t.In(i)
+	}
+
+	values := make([]reflect.Value, 0, len(argTypes))
+	for len(argTypes) > 0 && isInjected(argTypes[0]) {
+		if argTypes[0] == sessionType {
+			values = append(values, reflect.ValueOf(s))
+		} else {
+			values = append(values, reflect.ValueOf(s.Context()))
+		}
+		argTypes = argTypes[1:]
+	}
+	ni := len(argTypes)
+
+	if isStructBinding(argTypes) {
+		arg, err := c.bindStruct(s, argTypes[0], args)
+		if err != nil {
+			return c.handleErr(err, args)
+		}
+		return c.call(s, v, append(values, arg), args)
+	}
+
+	rest := s.cli.absorbsRest(t, argTypes)
+	fixed := argTypes
+	if rest {
+		fixed = argTypes[:ni-1]
+	}
 
 	argsLen := len(args)
-	if argsLen < ni {
-		return c.handleErr(ErrCmdMissingArgs, args)
+	if argsLen < len(fixed) {
+		return c.handleErr(&ArgumentError{
+			Cmd:   c.Name,
+			Index: argsLen,
+			Want:  fixed[argsLen],
+			Err:   ErrCmdMissingArgs,
+		}, args)
 	}
 
-	if argsLen > ni && c.Completer != nil &&
-		len(c.Completer("")) > 0 {
-		return c.handleErr(ErrCmdInvalidArgs, args)
+	if completer := c.completer(); !rest && argsLen > ni && completer != nil &&
+		len(completer("")) > 0 {
+		return c.handleErr(&ArgumentError{
+			Cmd:   c.Name,
+			Index: ni,
+			Token: args[ni],
+			Err:   ErrCmdInvalidArgs,
+		}, args)
 	}
 
-	var argTypes []reflect.Type
-	for i := 0; i < ni; i++ {
-		argTypes = append(argTypes, t.In(i))
+	convert := func(i int, argType reflect.Type) (reflect.Value, error) {
+		argValue, err := s.cli.convertArg(argType, args[i])
+		if err != nil {
+			return argValue, &ArgumentError{
+				Cmd:   c.Name,
+				Index: i,
+				Token: args[i],
+				Want:  argType,
+				Err:   err,
+			}
+		}
+		return argValue, nil
 	}
 
-	var values []reflect.Value
-	for i, arg := range args[:ni] {
-		argValue, err := convertStringToType(argTypes[i], arg)
+	for i, argType := range fixed {
+		argValue, err := convert(i, argType)
 		if err != nil {
 			return c.handleErr(err, args)
 		}
 		values = append(values, argValue)
 	}
 
-	v.Call(values)
+	if rest {
+		sliceType := argTypes[ni-1]
+		slice := reflect.MakeSlice(sliceType, 0, argsLen-len(fixed))
+		for i := len(fixed); i < argsLen; i++ {
+			argValue, err := convert(i, sliceType.Elem())
+			if err != nil {
+				return c.handleErr(err, args)
+			}
+			slice = reflect.Append(slice, argValue)
+		}
+		values = append(values, slice)
+	}
+
+	return c.call(s, v, values, args)
+}
+
+// call calls the Function with the given values, keeping its result and
+// handling the error it returns, if any.
+func (c *Command) call(s *Session, v reflect.Value, values []reflect.Value, args []string) error {
+	var out []reflect.Value
+	if v.Type().IsVariadic() {
+		out = v.CallSlice(values)
+	} else {
+		out = v.Call(values)
+	}
+	if result, ok := firstResult(out); ok {
+		s.setLastResult(result)
+		s.printResult(result)
+	}
+
+	if err := resultError(out); err != nil {
+		return c.handleErr(err, args)
+	}
+	return nil
+}
+
+// parseFlags resets Flags to their default values and parses args, returning
+// the remaining positional arguments, or false if the Function is not to be
+// called because help was requested or the flags were invalid.
+func (c *Command) parseFlags(args []string) ([]string, bool, error) {
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+	})
+
+	err := c.Flags.Parse(args)
+	if err == flag.ErrHelp {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, c.handleErr(fmt.Errorf("%w: %v", ErrCmdInvalidArgs, err), args)
+	}
+	return c.Flags.Args(), true, nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
+// resultError returns the error returned by a Function, if any.
+func resultError(out []reflect.Value) error {
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i].Type() == errorType {
+			err, _ := out[i].Interface().(error)
+			return err
+		}
+	}
 	return nil
 }
 
+// firstResult returns the first value returned by a Function that is not of
+// type error, if any.
+func firstResult(out []reflect.Value) (interface{}, bool) {
+	for _, o := range out {
+		if o.Type() != errorType {
+			return o.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// convertArg converts a CLI argument to a value of type t, using the Converter
+// registered for it, if any. On top of the Kinds supported by
+// convertStringToType, time.Duration values are parsed with
+// time.ParseDuration, and time.Time values as RFC 3339 or with the layout set
+// via GomCLI.SetTimeLayout.
+func (c *GomCLI) convertArg(t reflect.Type, strVal string) (reflect.Value, error) {
+	if conv, ok := c.converters[t]; ok {
+		val, err := conv(strVal)
+		if err != nil {
+			return reflect.Zero(t), err
+		}
+		if val == nil {
+			return reflect.Zero(t), nil
+		}
+		rv := reflect.ValueOf(val)
+		if !rv.Type().AssignableTo(t) {
+			if !rv.Type().ConvertibleTo(t) {
+				return reflect.Zero(t), ErrCmdInvalidArgs
+			}
+			rv = rv.Convert(t)
+		}
+		return rv, nil
+	}
+
+	switch t {
+	case durationType:
+		d, err := time.ParseDuration(strVal)
+		return reflect.ValueOf(d), err
+	case timeType:
+		tm, err := time.Parse(time.RFC3339, strVal)
+		if err != nil && c.timeLayout != "" {
+			tm, err = time.ParseInLocation(c.timeLayout, strVal, time.Local)
+		}
+		return reflect.ValueOf(tm), err
+	}
+	return convertStringToType(t, strVal)
+}
+
+// absorbsRest reports whether the last parameter of a Function receives all
+// the remaining arguments, being either variadic or a slice with no Converter
+// registered for it.
+func (c *GomCLI) absorbsRest(t reflect.Type, argTypes []reflect.Type) bool {
+	if t.IsVariadic() {
+		return true
+	}
+	if len(argTypes) == 0 {
+		return false
+	}
+	last := argTypes[len(argTypes)-1]
+	_, ok := c.converters[last]
+	return last.Kind() == reflect.Slice && !ok
+}
+
 // Borrowed from https://stackoverflow.com/q/39891689
 func convertStringToType(t reflect.Type, strVal string) (reflect.Value, error) {
 	result := reflect.Indirect(reflect.New(t))
@@ -0,0 +1,54 @@
+package gomcli
+
+import "strings"
+
+// SetConfirmMultiCommand sets whether input entered at the prompt that runs
+// several commands, once split on ";" and newlines and rewritten by the
+// InputTransformers, e.g. a pasted block or an expanded alias, is listed and
+// only run once confirmed. If declined, input of a single line is proposed
+// again at the next prompt so that it can be edited. The default is false.
+func (c *GomCLI) SetConfirmMultiCommand(value bool) {
+	c.confirmMulti = value
+}
+
+// confirmInput reports whether input, as entered and as rewritten by the
+// InputTransformers, can be run, asking for confirmation if it runs several
+// commands and SetConfirmMultiCommand is enabled.
+func (c *GomCLI) confirmInput(input, transformed string) bool {
+	if !c.confirmMulti {
+		return true
+	}
+	lines, err := c.parse(transformed)
+	if err != nil || len(lines) < 2 {
+		return true
+	}
+
+	s := c.session
+	s.Printf("The input runs %d commands:\n", len(lines))
+	for i, line := range lines {
+		s.Printf("  %d. %v\n", i+1, c.describeLine(line))
+	}
+	answer, err := c.lr.Prompt("Run them? [y/N] ")
+	if err == nil {
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		}
+	}
+
+	s.Println("Cancelled")
+	if !strings.ContainsAny(input, "\r\n") {
+		c.pending = input
+	}
+	return false
+}
+
+// describeLine returns the words of line, quoted, along with the programs it
+// is piped to, with the registered patterns and SecretArgs masked.
+func (c *GomCLI) describeLine(line ParsedLine) string {
+	parts := []string{quoteWords(c.maskSecrets(line))}
+	for _, p := range line.Pipes {
+		parts = append(parts, quoteWords(p.Values()))
+	}
+	return c.redactText(strings.Join(parts, " | "))
+}
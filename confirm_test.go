@@ -0,0 +1,34 @@
+package gomcli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmMultiCommand(t *testing.T) {
+	var out bytes.Buffer
+	c := NewWithIO(strings.NewReader("greet; login bob hunter2\ny\n"), &out)
+	c.SetConfirmMultiCommand(true)
+	transforms := 0
+	c.AddInputTransformer(func(s *Session, input string) (string, error) {
+		transforms++
+		return input, nil
+	})
+	var ran []string
+	c.AddCommand(Command{Name: "greet", Function: func() { ran = append(ran, "greet") }})
+	c.AddCommand(Command{Name: "login", SecretArgs: []int{1}, Function: func(user, password string) {
+		ran = append(ran, password)
+	}})
+
+	c.Start()
+	if transforms != 1 {
+		t.Errorf("InputTransformer ran %d times, want 1", transforms)
+	}
+	if len(ran) != 2 || ran[1] != "hunter2" {
+		t.Errorf("ran %q", ran)
+	}
+	if got := out.String(); strings.Contains(got, "hunter2") || !strings.Contains(got, "login bob ****") {
+		t.Errorf("confirmation listing = %q", got)
+	}
+}
@@ -0,0 +1,76 @@
+package gomcli
+
+// commandContext is a namespace entered with PushContext.
+type commandContext struct {
+	name     string
+	prompt   string
+	commands map[string]Command
+
+	// onPop, if set, is called when the context is left.
+	onPop func()
+}
+
+// PushContext enters a context named name, as in the configuration modes of
+// network appliances, e.g. "configure" and then "interface eth0": until it is
+// left with PopContext, dispatch and completion only see the given Commands
+// and the global ones, i.e. those registered outside any context, the former
+// taking precedence. Commands added with AddCommand while the context is
+// active belong to it. If prompt is not empty, it is displayed instead of the
+// usual one while the context is active.
+func (c *GomCLI) PushContext(name string, commands []Command, prompt string) {
+	c.contexts = append(c.contexts, commandContext{
+		name:     name,
+		prompt:   prompt,
+		commands: c.commands,
+	})
+
+	c.commands = make(map[string]Command, len(c.globalCommands())+len(commands))
+	for k, cmd := range c.globalCommands() {
+		c.commands[k] = cmd
+	}
+	for _, cmd := range commands {
+		c.commands[cmd.Name] = cmd
+	}
+}
+
+// PopContext leaves the context entered last with PushContext, going back to
+// the enclosing one, if any. It does nothing if no context is active.
+func (c *GomCLI) PopContext() {
+	n := len(c.contexts)
+	if n == 0 {
+		return
+	}
+	ctx := c.contexts[n-1]
+	c.commands = ctx.commands
+	c.contexts = c.contexts[:n-1]
+	if ctx.onPop != nil {
+		ctx.onPop()
+	}
+}
+
+// Context returns the names of the active contexts, outermost first, or nil if
+// there is none.
+func (c *GomCLI) Context() []string {
+	var names []string
+	for _, ctx := range c.contexts {
+		names = append(names, ctx.name)
+	}
+	return names
+}
+
+// globalCommands returns the Commands registered outside any context.
+func (c *GomCLI) globalCommands() map[string]Command {
+	if len(c.contexts) == 0 {
+		return c.commands
+	}
+	return c.contexts[0].commands
+}
+
+// contextPrompt returns the prompt of the active context, or an empty string
+// if there is none.
+func (c *GomCLI) contextPrompt() string {
+	if n := len(c.contexts); n > 0 {
+		return c.contexts[n-1].prompt
+	}
+	return ""
+}
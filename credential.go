@@ -0,0 +1,62 @@
+package gomcli
+
+import (
+	"sync"
+	"time"
+)
+
+type credential struct {
+	value   string
+	expires time.Time
+}
+
+type credentialCache struct {
+	sync.Mutex
+	entries map[string]credential
+}
+
+// Credential returns the secret identified by name, prompting for it with
+// prompt if it is not cached. Entered secrets are kept in memory for ttl, after
+// which the user is prompted again; a ttl of zero keeps them until Close. The
+// input is not echoed nor added to the history.
+func (c *GomCLI) Credential(name string, prompt string, ttl time.Duration) (string, error) {
+	c.credentials.Lock()
+	defer c.credentials.Unlock()
+
+	if cred, ok := c.credentials.entries[name]; ok {
+		if cred.expires.IsZero() || time.Now().Before(cred.expires) {
+			return cred.value, nil
+		}
+		delete(c.credentials.entries, name)
+	}
+
+	value, err := c.lr.PasswordPrompt(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	cred := credential{value: value}
+	if ttl > 0 {
+		cred.expires = time.Now().Add(ttl)
+	}
+	if c.credentials.entries == nil {
+		c.credentials.entries = make(map[string]credential)
+	}
+	c.credentials.entries[name] = cred
+
+	return value, nil
+}
+
+// ForgetCredential removes the secret identified by name from the cache, so that
+// the next call to Credential prompts for it again.
+func (c *GomCLI) ForgetCredential(name string) {
+	c.credentials.Lock()
+	defer c.credentials.Unlock()
+	delete(c.credentials.entries, name)
+}
+
+func (c *GomCLI) forgetCredentials() {
+	c.credentials.Lock()
+	defer c.credentials.Unlock()
+	c.credentials.entries = nil
+}
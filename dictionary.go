@@ -0,0 +1,135 @@
+package gomcli
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Dictionary is a set of words, such as host names or user names, that can be
+// shared by the Completers of several Commands, see GomCLI.Dictionary. It is
+// safe for concurrent use.
+type Dictionary struct {
+	mu    sync.RWMutex
+	words map[string]bool
+}
+
+// Dictionary returns the Dictionary named name, creating it empty if it does
+// not exist yet.
+func (c *GomCLI) Dictionary(name string) *Dictionary {
+	c.dictMu.Lock()
+	defer c.dictMu.Unlock()
+	if c.dictionaries == nil {
+		c.dictionaries = make(map[string]*Dictionary)
+	}
+	d, ok := c.dictionaries[name]
+	if !ok {
+		d = &Dictionary{words: make(map[string]bool)}
+		c.dictionaries[name] = d
+	}
+	return d
+}
+
+// Publish makes names, such as the hosts or sessions created by a Command,
+// available for completion to the Commands whose Entities is kind. They are
+// kept in the Dictionary named kind.
+func (s *Session) Publish(kind string, names ...string) {
+	s.cli.Dictionary(kind).Add(names...)
+}
+
+// Unpublish removes names published with Publish, e.g. when the entities they
+// refer to are deleted.
+func (s *Session) Unpublish(kind string, names ...string) {
+	s.cli.Dictionary(kind).Remove(names...)
+}
+
+// Add adds words to the Dictionary. Empty words are ignored.
+func (d *Dictionary) Add(words ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range words {
+		if w != "" {
+			d.words[w] = true
+		}
+	}
+}
+
+// Remove removes words from the Dictionary.
+func (d *Dictionary) Remove(words ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range words {
+		delete(d.words, w)
+	}
+}
+
+// LoadFile adds the words of the file at path, one per line, skipping blank
+// lines and lines starting with "#".
+func (d *Dictionary) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && line[0] != '#' {
+			words = append(words, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	d.Add(words...)
+	return nil
+}
+
+// LoadHistory adds the argument at index arg of the invocations of the
+// Command named name found in the history of c, e.g. the hosts previously
+// passed to "connect".
+func (d *Dictionary) LoadHistory(c *GomCLI, name string, arg int) {
+	var words []string
+	for _, entry := range c.history {
+		lines, _ := c.parse(entry.Line)
+		for _, line := range lines {
+			line = c.stripAssignments(line)
+			tokens := line.Values()
+			cmd, i := c.lookupCommand(tokens)
+			if cmd != nil && cmd.Name == name && i+arg < len(tokens) {
+				words = append(words, tokens[i+arg])
+			}
+		}
+	}
+	d.Add(words...)
+}
+
+// Words returns the words of the Dictionary, sorted.
+func (d *Dictionary) Words() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	words := make([]string, 0, len(d.words))
+	for w := range d.words {
+		words = append(words, w)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Completer returns a Completer proposing the words of the Dictionary starting
+// with the text being completed, including the words added afterwards.
+func (d *Dictionary) Completer() Completer {
+	return func(prefix string) []string {
+		var res []string
+		for _, w := range d.Words() {
+			if strings.HasPrefix(w, prefix) {
+				res = append(res, w)
+			}
+		}
+		return res
+	}
+}
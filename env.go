@@ -0,0 +1,181 @@
+package gomcli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnableAssignments sets whether input starting with "NAME=value" words sets
+// the session variables, or, when followed by a command, sets them along with
+// the environment variables of the same names just while the command runs, as
+// in shells, e.g. "DEBUG=1 run". Assignments are rejected while Restrictions
+// with an Allow list or NoAssignments are in effect. The default is false, so
+// that such words are taken as Command names.
+func (c *GomCLI) EnableAssignments(value bool) {
+	c.assignments = value
+}
+
+// checkAssignments returns an error wrapping ErrCliCommandNotAllowed if any of
+// the Restrictions in effect does not allow assignments.
+func (c *GomCLI) checkAssignments() error {
+	for _, r := range c.restrictions {
+		if r.NoAssignments || r.Allow != nil {
+			return fmt.Errorf("%w: %q", ErrCliCommandNotAllowed, "NAME=value")
+		}
+	}
+	return nil
+}
+
+// stripAssignments returns line without its leading assignments, if enabled
+// with EnableAssignments.
+func (c *GomCLI) stripAssignments(line ParsedLine) ParsedLine {
+	if c.assignments {
+		_, line = splitAssignments(line)
+	}
+	return line
+}
+
+// EnableEnv registers the built-in "export" and "env" Commands. "export
+// NAME=value" sets both the environment variable of the process and the
+// session variable NAME, and "export NAME" exports the current value of the
+// session variable, except for a variable named last once EnableExport is
+// enabled, as "export last" runs its Command instead. "export" does not run
+// while Restrictions with NoAssignments are in effect. "env" lists the
+// environment, or shows the given variables.
+func (c *GomCLI) EnableEnv() {
+	c.AddCommand(Command{
+		Name:    "export",
+		Help:    "Set environment variables",
+		Usage:   "export NAME[=value]...",
+		builtin: exportEnvBuiltin,
+		assigns: true,
+	})
+	c.AddCommand(Command{
+		Name:    "env",
+		Help:    "Show environment variables",
+		Usage:   "env [NAME]...",
+		builtin: envBuiltin,
+	})
+}
+
+func exportEnvBuiltin(s *Session, args []string) error {
+	if len(args) == 0 {
+		s.Println("Usage: export NAME[=value]...")
+		return nil
+	}
+
+	for _, arg := range args {
+		name, value, ok := splitAssignment(arg)
+		if !ok {
+			if value, ok = s.Var(arg); !ok {
+				s.Printf("Variable %q is not set\n", arg)
+				continue
+			}
+			name = arg
+		}
+		if err := os.Setenv(name, value); err != nil {
+			s.Println(err)
+			continue
+		}
+		s.SetVar(name, value)
+	}
+	return nil
+}
+
+func envBuiltin(s *Session, args []string) error {
+	if len(args) > 0 {
+		for _, name := range args {
+			if value, ok := os.LookupEnv(name); ok {
+				s.Printf("%v=%v\n", name, value)
+			}
+		}
+		return nil
+	}
+
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, kv := range environ {
+		s.Println(kv)
+	}
+	return nil
+}
+
+// splitAssignment splits "NAME=value" into its parts, reporting whether arg is
+// an assignment to a valid variable name.
+func splitAssignment(arg string) (string, string, bool) {
+	i := strings.IndexByte(arg, '=')
+	if i <= 0 || !isVarName(arg[:i]) {
+		return "", "", false
+	}
+	return arg[:i], arg[i+1:], true
+}
+
+// isVarName reports whether name is made of letters, digits and underscores,
+// not starting with a digit.
+func isVarName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// splitAssignments splits the leading "NAME=value" words off line, as in
+// "DEBUG=1 run", returning them along with the rest of the line. The name must
+// not be quoted nor escaped for a word to be an assignment.
+func splitAssignments(line ParsedLine) ([]Token, ParsedLine) {
+	n := 0
+	for ; n < len(line.Tokens); n++ {
+		name, _, ok := splitAssignment(line.Tokens[n].Value)
+		if !ok || !strings.HasPrefix(line.Tokens[n].Raw, name+"=") {
+			break
+		}
+	}
+	if n == 0 {
+		return nil, line
+	}
+
+	rest := ParsedLine{Tokens: line.Tokens[n:], Separator: line.Separator, Pipes: line.Pipes}
+	if len(rest.Tokens) > 0 {
+		rest.Offset = rest.Tokens[0].Start
+		rest.Raw = line.Raw[rest.Offset-line.Offset:]
+	}
+	return line.Tokens[:n], rest
+}
+
+// assign sets the session variables and environment variables of assigns,
+// returning a function that restores their previous values.
+func (s *Session) assign(assigns []Token) (restore func()) {
+	var undo []func()
+	for _, tok := range assigns {
+		name, value, _ := splitAssignment(tok.Value)
+		prevVar, hadVar := s.Var(name)
+		prevEnv, hadEnv := os.LookupEnv(name)
+		undo = append(undo, func() {
+			if hadVar {
+				s.SetVar(name, prevVar)
+			} else {
+				s.UnsetVar(name)
+			}
+			if hadEnv {
+				os.Setenv(name, prevEnv)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		s.SetVar(name, value)
+		os.Setenv(name, value)
+	}
+
+	return func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+}
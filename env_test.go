@@ -0,0 +1,79 @@
+package gomcli
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestAssignmentWithPipe(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep not available")
+	}
+
+	var out bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.EnableShellPipes(true)
+	c.EnableAssignments(true)
+	c.AddCommand(Command{Name: "show", Function: func(s *Session) {
+		s.Println("hello")
+		s.Println("world")
+	}})
+
+	if err := c.processInput("X=1 show | grep world"); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "world\n" {
+		t.Errorf("output = %q, want %q", got, "world\n")
+	}
+}
+
+func TestAssignmentsDisabled(t *testing.T) {
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	var notFound string
+	c.SetNotFoundHandler(func(name string) error {
+		notFound = name
+		return nil
+	})
+
+	c.processInput("foo=bar")
+	if notFound != "foo=bar" {
+		t.Errorf("NotFoundHandler got %q, want %q", notFound, "foo=bar")
+	}
+	if _, ok := c.Session().Var("foo"); ok {
+		t.Error("foo is set")
+	}
+}
+
+func TestAssignmentsRestricted(t *testing.T) {
+	var out bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.EnableAssignments(true)
+	ran := false
+	c.AddCommand(Command{Name: "ok", Function: func() { ran = true }})
+	c.SetRestrictions(&Restrictions{Allow: []string{"ok"}})
+
+	c.processInput("GOMCLI_TEST_VAR=evil ok")
+	if ran {
+		t.Error("ok ran")
+	}
+	if _, ok := os.LookupEnv("GOMCLI_TEST_VAR"); ok {
+		t.Error("GOMCLI_TEST_VAR is set")
+	}
+}
+
+func TestExportRestricted(t *testing.T) {
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.EnableEnv()
+	c.SetRestrictions(&Restrictions{NoAssignments: true})
+
+	c.processInput("export GOMCLI_TEST_EXPORT=evil")
+	if _, ok := os.LookupEnv("GOMCLI_TEST_EXPORT"); ok {
+		os.Unsetenv("GOMCLI_TEST_EXPORT")
+		t.Error("export ran under NoAssignments")
+	}
+	if _, ok := c.Session().Var("GOMCLI_TEST_EXPORT"); ok {
+		t.Error("GOMCLI_TEST_EXPORT is set")
+	}
+}
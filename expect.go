@@ -0,0 +1,150 @@
+package gomcli
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotAutomated is returned by Session.SendLine and Session.Expect if
+// GomCLI.Automate has not been called.
+var ErrSessionNotAutomated = errors.New("Session not automated")
+
+// ErrExpectTimeout is returned by Session.Expect when the pattern is not
+// printed within the timeout.
+var ErrExpectTimeout = errors.New("Timeout waiting for output")
+
+// Automate switches the CLI to programmatic input and output, for tests or to
+// proxy a session: instead of the terminal, input is read from the lines sent
+// with Session.SendLine, and the prompts and output are kept to be matched with
+// Session.Expect. It must be called before Start, and returns the Session to
+// drive the conversation with.
+func (c *GomCLI) Automate() *Session {
+	in := newLineQueue()
+	out := newExpectBuffer()
+
+	c.lr.Close()
+	c.lr = newDumbReader(in, out)
+	c.batchInput = nil
+
+	c.session.in, c.session.expect = in, out
+	c.session.setOutput(out)
+
+	return c.session
+}
+
+// SendLine sends line as input to the CLI, as if the user typed it and pressed
+// Enter.
+func (s *Session) SendLine(line string) error {
+	if s.in == nil {
+		return ErrSessionNotAutomated
+	}
+	_, err := s.in.Write([]byte(line + "\n"))
+	return err
+}
+
+// Expect waits until the CLI prints output matching pattern, and returns the
+// output printed since the previous call up to the end of the match. Output
+// before the match is consumed, so that each call waits for new output.
+func (s *Session) Expect(pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	if s.expect == nil {
+		return "", ErrSessionNotAutomated
+	}
+	return s.expect.expect(pattern, timeout)
+}
+
+// lineQueue is an io.Reader returning the data written to it, blocking until
+// there is some available or it is closed.
+type lineQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    []byte
+	closed bool
+}
+
+func newLineQueue() *lineQueue {
+	q := &lineQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *lineQueue) Write(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, io.ErrClosedPipe
+	}
+	q.buf = append(q.buf, p...)
+	q.cond.Broadcast()
+	return len(p), nil
+}
+
+func (q *lineQueue) Read(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.buf) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, q.buf)
+	q.buf = q.buf[n:]
+	return n, nil
+}
+
+func (q *lineQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+	return nil
+}
+
+// expectBuffer is an io.Writer keeping the output not yet consumed by expect.
+type expectBuffer struct {
+	mu      sync.Mutex
+	buf     []byte
+	changed chan struct{}
+}
+
+func newExpectBuffer() *expectBuffer {
+	return &expectBuffer{changed: make(chan struct{})}
+}
+
+func (e *expectBuffer) Write(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf = append(e.buf, p...)
+	close(e.changed)
+	e.changed = make(chan struct{})
+	return len(p), nil
+}
+
+func (e *expectBuffer) expect(pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		e.mu.Lock()
+		if loc := pattern.FindIndex(e.buf); loc != nil {
+			out := string(e.buf[:loc[1]])
+			e.buf = e.buf[loc[1]:]
+			e.mu.Unlock()
+			return out, nil
+		}
+		changed := e.changed
+		e.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			e.mu.Lock()
+			out := string(e.buf)
+			e.mu.Unlock()
+			return out, ErrExpectTimeout
+		}
+	}
+}
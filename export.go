@@ -0,0 +1,252 @@
+package gomcli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrExportNotTabular is returned by WriteCSV when the value cannot be
+// represented as rows and columns.
+var ErrExportNotTabular = errors.New("Result is not tabular")
+
+// WriteCSV writes v to w as delimited text, using comma as the field separator
+// (e.g. ',' for CSV or '\t' for TSV). v can be a slice or array of structs, maps
+// or slices, or a single struct or map, which is written as one row. Structs
+// use their exported field names, or their json tag, as header; maps use their
+// sorted keys.
+func WriteCSV(w io.Writer, v interface{}, comma rune) error {
+	header, rows, err := tabulate(v)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// ResultFormat is the format in which the results of Commands are printed, see
+// SetResultFormat.
+type ResultFormat int
+
+const (
+	// ResultNone does not print the results.
+	ResultNone ResultFormat = iota
+	// ResultCSV prints the results as CSV.
+	ResultCSV
+	// ResultTSV prints the results as TSV.
+	ResultTSV
+)
+
+// SetResultFormat sets the format in which the result returned by the Function
+// of each Command, see Session.LastResult, is printed, as written by WriteCSV.
+// Results that are not tabular are printed as with fmt.Println. The default is
+// ResultNone.
+func (c *GomCLI) SetResultFormat(format ResultFormat) {
+	c.resultFormat = format
+}
+
+// printResult prints result in the format set with SetResultFormat.
+func (s *Session) printResult(result interface{}) {
+	comma := ','
+	switch s.cli.resultFormat {
+	case ResultNone:
+		return
+	case ResultTSV:
+		comma = '\t'
+	}
+
+	err := WriteCSV(s.Writer(), result, comma)
+	if errors.Is(err, ErrExportNotTabular) {
+		s.Println(result)
+	} else if err != nil {
+		s.Println(err)
+	}
+}
+
+// EnableExport registers a built-in "export last" Command, which writes the
+// last result to the given file as TSV if its extension is .tsv, or as CSV
+// otherwise, and a built-in "set output" Command, which takes "csv", "tsv" or
+// "off" to set the format in which results are printed, see SetResultFormat.
+func (c *GomCLI) EnableExport() {
+	c.AddCommand(Command{
+		Name:    "export last",
+		Help:    "Export the last result to a CSV or TSV file",
+		Usage:   "export last <file>",
+		builtin: exportBuiltin,
+		files:   true,
+	})
+	c.AddCommand(Command{
+		Name:    "set output",
+		Help:    "Print the results of commands as CSV or TSV",
+		Usage:   "set output csv|tsv|off",
+		Args:    []Arg{{Name: "format", Choices: []string{"csv", "tsv", "off"}}},
+		builtin: setOutputBuiltin,
+	})
+}
+
+func setOutputBuiltin(s *Session, args []string) error {
+	formats := map[string]ResultFormat{"csv": ResultCSV, "tsv": ResultTSV, "off": ResultNone}
+	format, ok := ResultNone, len(args) == 1
+	if ok {
+		format, ok = formats[args[0]]
+	}
+	if !ok {
+		s.Println("Usage: set output csv|tsv|off")
+		return nil
+	}
+	s.cli.SetResultFormat(format)
+	return nil
+}
+
+func exportBuiltin(s *Session, args []string) error {
+	if len(args) != 1 {
+		s.Println("Usage: export last <file>")
+		return nil
+	}
+
+	result := s.LastResult()
+	if result == nil {
+		s.Println(ErrQueryNoResult)
+		return nil
+	}
+
+	comma := ','
+	if strings.EqualFold(filepath.Ext(args[0]), ".tsv") {
+		comma = '\t'
+	}
+
+	f, err := os.Create(s.Abs(args[0]))
+	if err != nil {
+		s.Println(err)
+		return nil
+	}
+	defer f.Close()
+
+	if err := WriteCSV(f, result, comma); err != nil {
+		s.Println(err)
+	}
+	return nil
+}
+
+func tabulate(v interface{}) ([]string, [][]string, error) {
+	rv := indirectValue(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+		return tabulateRows([]reflect.Value{rv})
+	case reflect.Slice, reflect.Array:
+		rows := make([]reflect.Value, rv.Len())
+		for i := range rows {
+			rows[i] = indirectValue(rv.Index(i))
+		}
+		return tabulateRows(rows)
+	default:
+		return nil, nil, ErrExportNotTabular
+	}
+}
+
+func tabulateRows(rows []reflect.Value) ([]string, [][]string, error) {
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	var header []string
+	var out [][]string
+	switch first := rows[0]; first.Kind() {
+	case reflect.Struct:
+		var fields []int
+		t := first.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			header = append(header, name)
+			fields = append(fields, i)
+		}
+		for _, row := range rows {
+			if row.Type() != t {
+				return nil, nil, ErrExportNotTabular
+			}
+			record := make([]string, len(fields))
+			for j, i := range fields {
+				record[j] = fmt.Sprint(row.Field(i).Interface())
+			}
+			out = append(out, record)
+		}
+	case reflect.Map:
+		keys := map[string]bool{}
+		for _, row := range rows {
+			if row.Kind() != reflect.Map {
+				return nil, nil, ErrExportNotTabular
+			}
+			for _, k := range row.MapKeys() {
+				keys[fmt.Sprint(k.Interface())] = true
+			}
+		}
+		for k := range keys {
+			header = append(header, k)
+		}
+		sort.Strings(header)
+		for _, row := range rows {
+			values := map[string]string{}
+			iter := row.MapRange()
+			for iter.Next() {
+				values[fmt.Sprint(iter.Key().Interface())] = fmt.Sprint(iter.Value().Interface())
+			}
+			record := make([]string, len(header))
+			for j, k := range header {
+				record[j] = values[k]
+			}
+			out = append(out, record)
+		}
+	case reflect.Slice, reflect.Array:
+		for _, row := range rows {
+			if row.Kind() != reflect.Slice && row.Kind() != reflect.Array {
+				return nil, nil, ErrExportNotTabular
+			}
+			record := make([]string, row.Len())
+			for j := range record {
+				record[j] = fmt.Sprint(row.Index(j).Interface())
+			}
+			out = append(out, record)
+		}
+	default:
+		for _, row := range rows {
+			if !row.IsValid() {
+				return nil, nil, ErrExportNotTabular
+			}
+			out = append(out, []string{fmt.Sprint(row.Interface())})
+		}
+	}
+	return header, out, nil
+}
+
+func indirectValue(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+package gomcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type exportRow struct {
+	Name string
+	Port int
+}
+
+func newExportCLI(out *bytes.Buffer) *GomCLI {
+	c := NewWithIO(&bytes.Buffer{}, out)
+	c.EnableExport()
+	c.AddCommand(Command{Name: "list", Function: func() []exportRow {
+		return []exportRow{{"alpha", 22}, {"beta", 80}}
+	}})
+	return c
+}
+
+func TestResultFormat(t *testing.T) {
+	var out bytes.Buffer
+	c := newExportCLI(&out)
+
+	c.processInput("list; set output tsv; list; set output off; list")
+	if want := "Name\tPort\nalpha\t22\nbeta\t80\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestExportLastUsesSessionDir(t *testing.T) {
+	var out bytes.Buffer
+	c := newExportCLI(&out)
+	dir := t.TempDir()
+	if err := c.Session().Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	c.processInput("list; export last rows.csv")
+	data, err := os.ReadFile(filepath.Join(dir, "rows.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Name,Port\nalpha,22\nbeta,80\n"; string(data) != want {
+		t.Errorf("rows.csv = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !windows && !linux && !freebsd && !netbsd && !openbsd
+// +build !darwin,!windows,!linux,!freebsd,!netbsd,!openbsd
+
+package gomcli
+
+import "os"
+
+// lockFile is a no-op on platforms without file locking support.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
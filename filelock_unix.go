@@ -0,0 +1,19 @@
+//go:build darwin || linux || freebsd || netbsd || openbsd
+// +build darwin linux freebsd netbsd openbsd
+
+package gomcli
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an exclusive advisory lock on f, waiting until it is
+// released by any other process.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,104 @@
+package gomcli
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetFuzzyCompletion sets whether, when no candidate starts with the text being
+// completed, candidates containing its characters in order are proposed
+// instead, best matches first, e.g. "get-cluster-role" for "gcr". It applies
+// to Command names, and to the arguments of the Commands with FuzzyComplete
+// set. The default is false.
+func (c *GomCLI) SetFuzzyCompletion(value bool) {
+	c.fuzzy = value
+}
+
+// fuzzyMatches returns the candidates matching pattern as a subsequence,
+// ignoring case, best matches first. If they do not share pattern as a prefix,
+// which would make the line editor replace the input by a shorter common
+// prefix, only the best one is returned.
+func fuzzyMatches(pattern string, candidates []string) []string {
+	type match struct {
+		text  string
+		score int
+	}
+	var matches []match
+	for _, cand := range candidates {
+		if score, ok := fuzzyScore(pattern, cand); ok {
+			matches = append(matches, match{cand, score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if len(matches[i].text) != len(matches[j].text) {
+			return len(matches[i].text) < len(matches[j].text)
+		}
+		return matches[i].text < matches[j].text
+	})
+
+	res := make([]string, len(matches))
+	for i, m := range matches {
+		res[i] = m.text
+		if !strings.HasPrefix(m.text, pattern) && len(matches) > 1 {
+			return []string{matches[0].text}
+		}
+	}
+	return res
+}
+
+// fuzzyScore reports whether the runes of pattern appear in order in text,
+// ignoring case, and how well, taking the best of the possible alignments:
+// runes matched at the start of text or of its words, and consecutive ones,
+// score higher.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	// best[j] is the score of the best alignment of the runes of p so far,
+	// the last one matched at t[j], or -1 if there is none.
+	best := make([]int, len(t))
+	next := make([]int, len(t))
+	for i := range p {
+		for j := range t {
+			next[j] = -1
+			if t[j] != p[i] {
+				continue
+			}
+			bonus := 1
+			if j == 0 || strings.ContainsRune(" -_./:", t[j-1]) {
+				bonus += 8
+			}
+			if i == 0 {
+				next[j] = bonus
+				continue
+			}
+			for k := 0; k < j; k++ {
+				if best[k] < 0 {
+					continue
+				}
+				score := best[k] + bonus
+				if k == j-1 {
+					score += 4
+				}
+				if score > next[j] {
+					next[j] = score
+				}
+			}
+		}
+		best, next = next, best
+	}
+
+	score := -1
+	for _, s := range best {
+		if s > score {
+			score = s
+		}
+	}
+	return score, score >= 0
+}
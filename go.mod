@@ -1,8 +1,7 @@
 module github.com/jmreyes/gomcli
 
-go 1.14
+go 1.18
 
-require (
-	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be
-	github.com/peterh/liner v1.2.0
-)
+require github.com/peterh/liner v1.2.0
+
+require github.com/mattn/go-runewidth v0.0.3 // indirect
@@ -4,26 +4,131 @@ package gomcli
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
-
-	"github.com/anmitsu/go-shlex"
-	"github.com/peterh/liner"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ErrCliPromptAborted is returned from Start or StartWithInput when the
 // user presses Ctrl-C, if CtrlCAborts was set to true in the Conf struct.
 var ErrCliPromptAborted = errors.New("Prompt aborted")
 
-// ErrCliCannotParseLine is returned from Start or StartWithInput if the
-// input provided could not be be parsed to form command and arguments.
+// ErrCliCannotParseLine is returned from Start or StartWithInput, wrapped in a
+// *ParseError, if the input provided could not be be parsed to form command and
+// arguments.
 var ErrCliCannotParseLine = errors.New("Cannot parse line")
 
-// ErrCliCommandNotFound is passed to the notFoundHandler function if the input
+// ParseError is returned from Start or StartWithInput in place of
+// ErrCliCannotParseLine, which it wraps, to provide details on where and why
+// the input could not be parsed. Offset is the byte offset within Line, and
+// LineNumber and Column locate that offset within multi-line input.
+type ParseError struct {
+	Line       string
+	Offset     int
+	LineNumber int
+	Column     int
+	Reason     string
+}
+
+func newParseError(line string, offset int, reason string) *ParseError {
+	lineStart := strings.LastIndex(line[:offset], "\n") + 1
+	return &ParseError{
+		Line:       line,
+		Offset:     offset,
+		LineNumber: strings.Count(line[:offset], "\n") + 1,
+		Column:     offset - lineStart + 1,
+		Reason:     reason,
+	}
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v: %v at line %d, column %d",
+		ErrCliCannotParseLine, e.Reason, e.LineNumber, e.Column)
+}
+
+// Unwrap returns ErrCliCannotParseLine, so that errors.Is can still be used.
+func (e *ParseError) Unwrap() error {
+	return ErrCliCannotParseLine
+}
+
+// ErrCliExitRequested can be returned by Commands, wrapped or not, to stop the
+// CLI as with Stop. Start and StartWithInput then return nil.
+var ErrCliExitRequested = errors.New("Exit requested")
+
+// ErrCliInputTooLarge is wrapped by the error returned from Start or
+// StartWithInput when the input exceeds the limits set with SetMaxInputLength
+// or SetMaxTokens.
+var ErrCliInputTooLarge = errors.New("Input too large")
+
+// ErrCliCommandNotFound is wrapped by CommandNotFoundError when the input
 // provided does not match any known command.
 var ErrCliCommandNotFound = errors.New("Command not found")
 
+// ErrCliRedirectCycle is wrapped by the error returned when the Redirects of
+// deprecated Commands lead back to one of them.
+var ErrCliRedirectCycle = errors.New("Redirect cycle")
+
+// CommandNotFoundError is returned when no Command can be found by Name. It
+// wraps ErrCliCommandNotFound, so that errors.Is can still be used.
+type CommandNotFoundError struct {
+	Name string
+}
+
+func (e *CommandNotFoundError) Error() string {
+	return fmt.Sprintf("%v: %q", ErrCliCommandNotFound, e.Name)
+}
+
+// Unwrap returns ErrCliCommandNotFound.
+func (e *CommandNotFoundError) Unwrap() error {
+	return ErrCliCommandNotFound
+}
+
+// ExecFunc executes a Command with the given arguments within a Session.
+type ExecFunc func(s *Session, cmd *Command, args []string) error
+
+// Middleware wraps the execution of every Command, see GomCLI.Use.
+type Middleware func(next ExecFunc) ExecFunc
+
+// Candidate is a completion proposed by a CompletionHook, that replaces the
+// bytes of the input line between Start and End by Text.
+type Candidate struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// CompletionHook is a function that computes the completions for the command
+// under the cursor, at byte offset pos of the input line. Offsets of line and
+// of the returned Candidates are relative to the input line as well.
+type CompletionHook func(line ParsedLine, pos int) []Candidate
+
+// InputTransformer is a function that rewrites the input before it is parsed,
+// e.g. to expand aliases or macros, see GomCLI.AddInputTransformer. An error
+// can be returned to reject the input, which will be propagated in the same
+// way as parse errors.
+type InputTransformer func(s *Session, input string) (string, error)
+
+// ArgsRewriter is a function that rewrites the arguments of a Command before
+// they are converted, e.g. to normalize host names or resolve short IDs, see
+// GomCLI.AddArgsRewriter. An error can be returned to reject them, which is
+// handled in the same way as conversion errors.
+type ArgsRewriter func(cmd *Command, args []string) ([]string, error)
+
+// PanicHandler is a function called when a Command's Function panics, after
+// recovering, so that the CLI can keep running. An error can be returned, that
+// will be propagated in the same way as Command errors.
+type PanicHandler func(*Command, *PanicError) error
+
 // NotFoundHandler is a function that indicates gomcli how to handle input
 // that does not match any known Command. If not set, default action is to ignore
 // it. An error can be returned, that will be propagated so that it is returned
@@ -33,27 +138,108 @@ type NotFoundHandler func(string) error
 // GomCLI represents the state of the command-line interface, and is the main
 // object to interact with within your program.
 type GomCLI struct {
-	lr              *liner.State
-	prompt          string
-	histfile        string
-	commands        map[string]Command
-	notFoundHandler NotFoundHandler
-	exitOnCmdError  bool
+	lr                 lineReader
+	prompt             string
+	promptFunc         func() string
+	continuationPrompt string
+	continueQuotes     bool
+	histStore          HistoryStore
+	histPending        bool
+	history            []HistoryEntry
+	historyLimit       int
+	histIgnoreDups     bool
+	histIgnoreSpace    bool
+	histEraseDups      bool
+	histExtended       bool
+	lastFailed         bool
+	commands           map[string]Command
+	notFoundHandler    NotFoundHandler
+	suggestionHandler  SuggestionHandler
+	exitOnCmdError     bool
+	deprecated         map[string]bool
+	pending            string
+	confirmMulti       bool
+	redactions         []*regexp.Regexp
+	credentials        credentialCache
+	session            *Session
+	slowThreshold      time.Duration
+	slowLogger         *log.Logger
+	timeLayout         string
+	converters         map[reflect.Type]Converter
+	duplicates         []string
+	strict             bool
+	middlewares        []Middleware
+	panicHandler       PanicHandler
+	completionHook     CompletionHook
+	partialHelp        bool
+	trace              bool
+	restrictions       []Restrictions
+	scriptVerifier     ScriptVerifier
+	scriptContinue     bool
+	detectors          []WorkspaceDetector
+	detected           bool
+	batchInput         io.Reader
+	trailingComments   bool
+	maxInputLength     int
+	maxTokens          int
+	shellPipes         bool
+	errOut             io.Writer
+	expandVars         bool
+	assignments        bool
+	captureOutput      bool
+	resultFormat       ResultFormat
+	stopped            int32
+	histErrHandler     func(error)
+	closeHooks         []func() error
+	closeOnce          sync.Once
+	closeErr           error
+	prePrompt          func(*Session)
+	transformers       []InputTransformer
+	contexts           []commandContext
+	rewriters          []ArgsRewriter
+	dictMu             sync.Mutex
+	dictionaries       map[string]*Dictionary
+	fuzzy              bool
 }
 
 // New initializes a new *GomCLI with sane defaults. Further configuration is
 // to be performed via the setters. The terminal is set to raw mode by Liner's
 // action, therefore to restore the terminal to its previous state,
-// GomCLI.Close() needs to be called.
+// GomCLI.Close() needs to be called. Since the terminal is shared, only one
+// GomCLI created with New can be running in the process at a time, while any
+// number of them created with NewWithIO can run alongside it.
 func New() *GomCLI {
+	c := newCLI()
+	c.session = newSession(c)
+	c.session.setOutput(os.Stdout)
+	c.lr = newLineReader(c)
+
+	return c
+}
+
+// NewWithIO initializes a new *GomCLI like New, reading the input from in and
+// writing the prompts and output to out instead of using the terminal, e.g.
+// for containers, tests or piped environments. There is no line editing nor
+// completion, and Start returns io.EOF when the input ends.
+func NewWithIO(in io.Reader, out io.Writer) *GomCLI {
+	c := newCLI()
+	c.lr = newDumbReader(in, out)
+	c.session = newSession(c)
+	c.session.setOutput(out)
+
+	return c
+}
+
+func newCLI() *GomCLI {
 	c := &GomCLI{}
 	c.prompt = "> "
+	c.continuationPrompt = "... "
+	c.historyLimit = defaultHistoryLimit
+	c.histIgnoreDups = true
 	c.commands = make(map[string]Command)
-
-	c.lr = liner.NewLiner()
-	c.lr.SetWordCompleter(c.complete)
-	c.lr.SetTabCompletionStyle(liner.TabPrints)
-
+	c.deprecated = make(map[string]bool)
+	c.timeLayout = "2006-01-02"
+	c.converters = make(map[reflect.Type]Converter)
 	return c
 }
 
@@ -63,6 +249,119 @@ func (c *GomCLI) SetPrompt(prompt string) {
 	c.prompt = prompt
 }
 
+// SetPromptFunc sets a function that is called before each prompt is displayed
+// to obtain it, so that the prompt can reflect the current state of the
+// application. It takes precedence over SetPrompt; set it to nil to go back to
+// the static prompt.
+func (c *GomCLI) SetPromptFunc(function func() string) {
+	c.promptFunc = function
+}
+
+// SetPromptTemplate sets a prompt whose variables in braces are replaced before
+// each prompt is displayed: {cwd} is the working directory of the Session with
+// the home directory abbreviated as "~", {cwdfull} the absolute one, {cwdbase}
+// its last element, and {?} the Session Status. Other variables are replaced
+// by the session variable of the same name, if set, e.g. "{workspace}:{cwd}> ".
+// It is a shorthand for SetPromptFunc.
+func (c *GomCLI) SetPromptTemplate(template string) {
+	c.promptFunc = func() string {
+		return c.expandPrompt(template)
+	}
+}
+
+func (c *GomCLI) expandPrompt(template string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		end := strings.IndexByte(template[start+1:], '}')
+		if start < 0 || end < 0 {
+			break
+		}
+		end += start + 1
+		b.WriteString(template[:start])
+		b.WriteString(c.promptVar(template[start : end+1]))
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
+
+// promptVar returns the value of the prompt variable in braces, or the
+// variable itself if unknown.
+func (c *GomCLI) promptVar(v string) string {
+	switch name := v[1 : len(v)-1]; name {
+	case "cwd":
+		return c.session.ShortDir()
+	case "cwdfull":
+		return c.session.Dir()
+	case "cwdbase":
+		return filepath.Base(c.session.Dir())
+	case "?":
+		return strconv.Itoa(c.session.Status())
+	default:
+		if value, ok := c.session.Var(name); ok {
+			return value
+		}
+	}
+	return v
+}
+
+// SetPrePromptHook sets a function called right before each prompt is
+// displayed, e.g. to poll for notifications or refresh a status line. What it
+// prints through the Session appears above the prompt, followed by the
+// messages queued with Session.Notify. Set it to nil to remove it.
+func (c *GomCLI) SetPrePromptHook(hook func(s *Session)) {
+	c.prePrompt = hook
+}
+
+func (c *GomCLI) currentPrompt() string {
+	if prompt := c.contextPrompt(); prompt != "" {
+		return prompt
+	}
+	if c.promptFunc != nil {
+		return c.promptFunc()
+	}
+	return c.prompt
+}
+
+// SetContinuationPrompt sets the prompt displayed to read the continuation of
+// a line ending in a backslash. The default is "... ".
+func (c *GomCLI) SetContinuationPrompt(prompt string) {
+	c.continuationPrompt = prompt
+}
+
+// SetContinueOnOpenQuote sets whether a line with unterminated quotes is
+// continued with the following lines until the quotes are closed, using the
+// continuation prompt, instead of failing with a *ParseError. The default is
+// false.
+func (c *GomCLI) SetContinueOnOpenQuote(value bool) {
+	c.continueQuotes = value
+}
+
+// SetTrailingComments sets whether an unquoted "#" starting any word, and not
+// only the first one of a command, starts a comment up to the end of the line,
+// e.g. "deploy prod # nightly". The default is false, so that arguments can
+// start with "#".
+func (c *GomCLI) SetTrailingComments(value bool) {
+	c.trailingComments = value
+}
+
+// SetMaxInputLength sets the maximum length in bytes of the input processed
+// at once, including continuation lines. Longer input is neither completed nor
+// recorded in the history, and running it fails with ErrCliInputTooLarge. A
+// limit of 0 or less, the default, means unlimited.
+func (c *GomCLI) SetMaxInputLength(n int) {
+	c.maxInputLength = n
+}
+
+// SetMaxTokens sets the maximum number of words of the input processed at
+// once, across all its commands. Parsing stops as soon as it is exceeded, and
+// running the input fails with ErrCliInputTooLarge. A limit of 0 or less, the
+// default, means unlimited.
+func (c *GomCLI) SetMaxTokens(n int) {
+	c.maxTokens = n
+}
+
 // SetCtrlCAborts sets whether Start will return an ErrPromptAborted when Ctrl-C
 // is pressed. The default is false (will not return when Ctrl-C is pressed).
 func (c *GomCLI) SetCtrlCAborts(aborts bool) {
@@ -75,11 +374,29 @@ func (c *GomCLI) SetNotFoundHandler(function NotFoundHandler) {
 	c.notFoundHandler = function
 }
 
+// SetPanicHandler sets the function that will be called when a Command's
+// Function panics. If not set, the panic is passed as a *PanicError to the
+// Command's ErrHandler.
+func (c *GomCLI) SetPanicHandler(function PanicHandler) {
+	c.panicHandler = function
+}
+
+// SetCompletionHook sets a function that, when not nil, replaces the built-in
+// completion of commands and arguments, e.g. to integrate a grammar-based
+// completion engine.
+func (c *GomCLI) SetCompletionHook(hook CompletionHook) {
+	c.completionHook = hook
+}
+
 // SetHistoryFile sets the path for the command history file. If not set, no history
-// file will be used. The history file has a fixed limit of 1000 entries.
+// file will be used. The number of entries kept is set via SetHistoryLimit. The
+// file is the default HistoryStore, see SetHistoryStore.
 func (c *GomCLI) SetHistoryFile(path string) {
-	c.histfile = path
-	c.setupHistory()
+	if path == "" {
+		c.SetHistoryStore(nil)
+		return
+	}
+	c.SetHistoryStore(&fileHistoryStore{cli: c, path: path})
 }
 
 // SetExitOnCmdError sets whether Start shall be interrupted and return the
@@ -88,96 +405,305 @@ func (c *GomCLI) SetExitOnCmdError(value bool) {
 	c.exitOnCmdError = value
 }
 
-// AddCommand adds a single Command to the CLI.
+// SetSlowCommandWarning sets a duration after which a Command is considered
+// slow. When the execution of a Command takes longer, a hint is printed after it
+// completes and, if logger is not nil, logged as well. A zero threshold, the
+// default, disables the warning.
+func (c *GomCLI) SetSlowCommandWarning(threshold time.Duration, logger *log.Logger) {
+	c.slowThreshold = threshold
+	c.slowLogger = logger
+}
+
+// SetTimeLayout sets the layout, as understood by time.Parse, accepted for
+// time.Time arguments besides RFC 3339. The default is "2006-01-02". Times
+// without a zone are interpreted in the local time zone.
+func (c *GomCLI) SetTimeLayout(layout string) {
+	c.timeLayout = layout
+}
+
+// RegisterConverter sets the Converter used for Function parameters of type t,
+// so that applications can accept their own types as arguments. The value
+// returned by conv must be assignable or convertible to t. Registered
+// Converters take precedence over the built-in conversions.
+func (c *GomCLI) RegisterConverter(t reflect.Type, conv Converter) {
+	c.converters[t] = conv
+}
+
+// SetStrict sets whether AddCommand and SetCommands panic when a Command is
+// registered with a name already in use, or does not pass the checks performed
+// by Validate on its own, instead of silently accepting it. The default is
+// false. Use RemoveCommand to replace a Command in strict mode.
+func (c *GomCLI) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+// SetTrace sets whether each command is echoed, prefixed by "+ ", right before
+// being dispatched, as in "set -x" of POSIX shells. The command is echoed once
+// its variables are expanded, including its leading "NAME=value" words, with
+// its secrets masked as in the history, see AddRedaction. The default is false.
+func (c *GomCLI) SetTrace(value bool) {
+	c.trace = value
+}
+
+// EnableTrace registers a built-in "set trace" Command, which takes "on" or
+// "off" to toggle tracing, see SetTrace.
+func (c *GomCLI) EnableTrace() {
+	c.AddCommand(Command{
+		Name:  "set trace",
+		Help:  "Echo commands before running them",
+		Usage: "set trace on|off",
+		Args:  []Arg{{Name: "state", Choices: []string{"on", "off"}}},
+		builtin: func(s *Session, args []string) error {
+			if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+				s.Printf("Usage: set trace on|off\n")
+				return nil
+			}
+			c.trace = args[0] == "on"
+			return nil
+		},
+	})
+}
+
+// SetOutput sets the writer used by the printer functions, the Session and the
+// built-in Commands, which is os.Stdout by default, e.g. to capture the output
+// in tests. The prompt and line editing still go to the terminal when Liner is
+// used. Note that the package-level printer functions are shared by all the
+// instances of the process, and follow the output set last or the latest active
+// Capture of any instance; Commands of CLIs meant to run alongside others
+// should print through their Session instead.
+func (c *GomCLI) SetOutput(w io.Writer) {
+	c.session.setOutput(w)
+}
+
+// SetErrOutput sets the writer used for the diagnostics printed by gomcli,
+// such as deprecation notices, slow Command warnings and traces. By default
+// they go to the same writer as the rest of the output.
+func (c *GomCLI) SetErrOutput(w io.Writer) {
+	c.session.outMu.Lock()
+	defer c.session.outMu.Unlock()
+	c.errOut = w
+}
+
+// Use appends a Middleware to the chain wrapping every Command execution, e.g.
+// to implement logging, authorization checks or timing. Middlewares are called
+// in the order they were added, each deciding whether to call next.
+func (c *GomCLI) Use(mw Middleware) {
+	c.middlewares = append(c.middlewares, mw)
+}
+
+// AddArgsRewriter appends an ArgsRewriter to the ones applied, in the order
+// they were added, to the arguments of every Command, before the Middlewares
+// are called.
+func (c *GomCLI) AddArgsRewriter(rw ArgsRewriter) {
+	c.rewriters = append(c.rewriters, rw)
+}
+
+// AddCommand adds a single Command to the CLI. It replaces any Command with
+// the same name, which is reported by Validate unless the replaced one is
+// built-in.
 func (c *GomCLI) AddCommand(cmd Command) {
-	c.commands[cmd.Name] = cmd
+	c.addCommand(cmd)
 }
 
 // SetCommands replaces the current CLI set of Commands by a new slice.
 func (c *GomCLI) SetCommands(cmds []Command) {
 	c.commands = make(map[string]Command)
+	c.duplicates = nil
 	for _, cmd := range cmds {
-		c.commands[cmd.Name] = cmd
+		c.addCommand(cmd)
 	}
 }
 
+func (c *GomCLI) addCommand(cmd Command) {
+	if prev, ok := c.commands[cmd.Name]; ok && prev.builtin == nil {
+		if c.strict {
+			panic(invalidCommand(cmd.Name, "registered more than once"))
+		}
+		c.duplicates = append(c.duplicates, cmd.Name)
+	}
+	if cmd.completer() == nil && cmd.Entities != "" {
+		cmd.Completer = c.Dictionary(cmd.Entities).Completer()
+	}
+	if c.strict {
+		if err := c.validateCommand(&cmd); err != nil {
+			panic(err)
+		}
+	}
+	c.commands[cmd.Name] = cmd
+}
+
 // RemoveCommand removes a specific Command from the CLI by name.
 func (c *GomCLI) RemoveCommand(name string) {
 	delete(c.commands, name)
 }
 
+// Session returns the Session shared with the Commands of the CLI.
+func (c *GomCLI) Session() *Session {
+	return c.session
+}
+
 // Commands retrieves the map with the current list of Commands for the CLI.
 func (c *GomCLI) Commands() map[string]Command {
 	return c.commands
 }
 
-func (c *GomCLI) setupHistory() {
-	if c.histfile == "" {
-		return
+func (c *GomCLI) complete(line string, pos int) (head string, comp []string, tail string) {
+	if c.maxInputLength > 0 && len(line) > c.maxInputLength {
+		return line[:pos], nil, line[pos:]
+	}
+	if c.completionHook != nil {
+		return c.hookComplete(line, pos)
 	}
 
-	f, err := os.Open(c.histfile)
-	if err != nil {
-		return
+	head, current := c.currentCommand(line[:pos])
+	tokens := current.Values()
+	tail = line[pos:]
+	if cmd, i := c.lookupCommand(tokens); cmd != nil {
+		if cmd.grammar != nil || len(cmd.ArgCompleters) > 0 {
+			head, args, search := argAt(line[:pos], current, i)
+			return head, c.completeArg(cmd, args, search), tail
+		}
+		if i == len(tokens) {
+			return head + current.Raw + " ", cmd.complete(""), tail
+		}
+		search := tokens[i]
+		comp = cmd.complete(search)
+		if len(comp) == 0 && c.fuzzy && cmd.FuzzyComplete {
+			comp = fuzzyMatches(search, cmd.complete(""))
+		}
+		return head + cmd.Name + " ", comp, tail
+	}
+	return head, c.rawCommandCompleter(line[len(head):pos]), tail
+}
+
+// argAt splits the input before the cursor, text, into the text preceding the
+// argument being typed, the arguments preceding it and the argument itself.
+// line is the command being typed within text, whose first i Tokens make up
+// the name of the Command.
+func argAt(text string, line ParsedLine, i int) (head string, args []string, search string) {
+	tokens := line.Values()
+	head, args = text, tokens[i:]
+	switch last := line.Tokens[len(line.Tokens)-1]; {
+	case last.End < len(text):
+	case len(args) == 0:
+		head += " "
+	default:
+		head, args, search = text[:last.Start], args[:len(args)-1], last.Value
 	}
-	c.lr.ReadHistory(f)
-	f.Close()
+	return
 }
 
-func (c *GomCLI) writeHistory() error {
-	if c.histfile == "" {
+// completeArg completes search, the argument of cmd following args, with the
+// Grammar of cmd or else its ArgCompleters.
+func (c *GomCLI) completeArg(cmd *Command, args []string, search string) []string {
+	var completer Completer
+	if cmd.grammar != nil {
+		completer = func(prefix string) []string {
+			return cmd.grammar.completeAt(c, args, prefix)
+		}
+	} else if len(args) < len(cmd.ArgCompleters) {
+		completer = cmd.ArgCompleters[len(args)]
+	}
+	if completer == nil {
 		return nil
 	}
 
-	dirName := filepath.Dir(c.histfile)
-	if _, err := os.Stat(dirName); err != nil {
-		err := os.MkdirAll(dirName, os.ModePerm)
-		if err != nil {
-			return err
+	comp := completer(search)
+	if len(comp) == 0 && c.fuzzy && cmd.FuzzyComplete {
+		comp = fuzzyMatches(search, completer(""))
+	}
+	return comp
+}
+
+// currentCommand splits the input before the cursor into the text preceding
+// the command being typed, and the command itself.
+func (c *GomCLI) currentCommand(text string) (string, ParsedLine) {
+	lines, _ := c.parse(text)
+	if len(lines) > 0 {
+		last := lines[len(lines)-1]
+		if last.Separator == SeparatorNone {
+			return text[:last.Offset], last
 		}
 	}
+	return text, ParsedLine{}
+}
 
-	f, err := os.Create(c.histfile)
-	if err != nil {
-		return err
+// hookComplete obtains the completions from the CompletionHook, extending them
+// to a common range of the line as expected by Liner.
+func (c *GomCLI) hookComplete(line string, pos int) (head string, comp []string, tail string) {
+	cands := c.completionHook(c.commandAt(line, pos), pos)
+	start, end := pos, pos
+	for i, cand := range cands {
+		cand.Start = clamp(cand.Start, 0, len(line))
+		cand.End = clamp(cand.End, cand.Start, len(line))
+		if cand.Start < start {
+			start = cand.Start
+		}
+		if cand.End > end {
+			end = cand.End
+		}
+		cands[i] = cand
 	}
-	c.lr.WriteHistory(f)
-	f.Close()
 
-	return nil
+	for _, cand := range cands {
+		comp = append(comp, line[start:cand.Start]+cand.Text+line[cand.End:end])
+	}
+	return line[:start], comp, line[end:]
 }
 
-func (c *GomCLI) complete(line string, pos int) (head string, comp []string, tail string) {
-	tokens, _ := shlex.Split(line[:pos], false)
-	tail = line[pos:]
-	for i := len(tokens); i > 0; i-- {
-		chunk := strings.Join(tokens[:i], " ")
-		if cmd, err := c.getCommand(chunk); err == nil {
-			if i == len(tokens) {
-				return strings.TrimSpace(line) + " ", cmd.complete(""), tail
-			}
-			search := tokens[i]
-			return cmd.Name + " ", cmd.complete(search), tail
+// commandAt returns the command of line under the cursor at pos, or an empty
+// ParsedLine if the cursor is not within nor right after one.
+func (c *GomCLI) commandAt(line string, pos int) ParsedLine {
+	lines, _ := c.parse(line)
+	for _, l := range lines {
+		if l.Offset > pos {
+			break
+		}
+		end := l.Offset + len(l.Raw)
+		if l.Separator == SeparatorNone || pos <= end+strings.IndexAny(line[end:], ";\n") {
+			return l
 		}
 	}
-	return head, c.rawCommandCompleter(line), tail
+	return ParsedLine{Offset: pos}
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
 }
 
 func (c *GomCLI) contextualComplete() []string {
 	keys := make([]string, 0, len(c.commands))
-	for k := range c.commands {
+	for k, cmd := range c.commands {
+		if cmd.Redirect != "" {
+			continue
+		}
 		keys = append(keys, k)
 	}
 	return keys
 }
 
 func (c *GomCLI) rawCommandCompleter(line string) (res []string) {
-	for _, cmd := range c.contextualComplete() {
-		if strings.HasPrefix(cmd, line) &&
-			len(strings.Split(cmd, " ")) == 1 {
+	names := c.contextualComplete()
+	for _, cmd := range names {
+		if strings.HasPrefix(cmd, line) && !strings.Contains(cmd, " ") {
 			res = append(res, cmd)
 		}
 	}
+	if len(res) == 0 && c.fuzzy && line != "" {
+		words := names[:0]
+		for _, cmd := range names {
+			if !strings.Contains(cmd, " ") {
+				words = append(words, cmd)
+			}
+		}
+		res = fuzzyMatches(line, words)
+	}
 	return
 }
 
@@ -185,24 +711,182 @@ func (c *GomCLI) getCommand(name string) (*Command, error) {
 	if cmd, ok := c.commands[name]; ok {
 		return &cmd, nil
 	}
-	return nil, ErrCliCommandNotFound
+	return nil, &CommandNotFoundError{Name: name}
+}
+
+// lookupCommand returns the Command named by the longest prefix of tokens, and
+// the number of tokens making up its name, or nil if there is none. The
+// candidate names are slices of a single string, so that probing does not
+// allocate.
+func (c *GomCLI) lookupCommand(tokens []string) (*Command, int) {
+	name := strings.Join(tokens, " ")
+	for i := len(tokens); i > 0; i-- {
+		if _, ok := c.commands[name]; ok {
+			cmd := c.commands[name]
+			return &cmd, i
+		}
+		if i > 1 {
+			name = name[:len(name)-len(tokens[i-1])-1]
+		}
+	}
+	return nil, 0
+}
+
+// resolveRedirect follows the Redirect chain of a deprecated Command, printing
+// a deprecation notice the first time it is invoked during the session.
+func (c *GomCLI) resolveRedirect(cmd *Command) (*Command, error) {
+	return c.followRedirects(cmd, true)
+}
+
+// followRedirects follows the Redirect chain of cmd, printing the deprecation
+// notices if notify is set, and returns the Command it ends at, or an error
+// wrapping ErrCliRedirectCycle if it leads back to one of its Commands.
+func (c *GomCLI) followRedirects(cmd *Command, notify bool) (*Command, error) {
+	chain := []string{cmd.Name}
+	for cmd.Redirect != "" {
+		if notify && !c.deprecated[cmd.Name] {
+			c.deprecated[cmd.Name] = true
+			c.errorf("[!] Command %q is deprecated, use %q instead\n", cmd.Name, cmd.Redirect)
+		}
+
+		for _, name := range chain {
+			if name == cmd.Redirect {
+				return nil, redirectCycleError(append(chain, cmd.Redirect))
+			}
+		}
+		target, err := c.getCommand(cmd.Redirect)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cmd.Redirect)
+		cmd = target
+	}
+	return cmd, nil
+}
+
+func redirectCycleError(chain []string) error {
+	quoted := make([]string, len(chain))
+	for i, name := range chain {
+		quoted[i] = strconv.Quote(name)
+	}
+	return fmt.Errorf("%w: %v", ErrCliRedirectCycle, strings.Join(quoted, " -> "))
+}
+
+// suggestionPrompter is implemented by the lineReader backends that support
+// line editing.
+type suggestionPrompter interface {
+	PromptWithSuggestion(prompt string, text string, pos int) (string, error)
+}
+
+func (c *GomCLI) readLine(prompt string, text string) (string, error) {
+	if sp, ok := c.lr.(suggestionPrompter); ok && text != "" {
+		return sp.PromptWithSuggestion(prompt, text, -1)
+	}
+	return c.lr.Prompt(prompt)
 }
 
 func (c *GomCLI) process() error {
-	userInput, err := c.lr.Prompt(c.prompt)
+	pending := c.pending
+	c.pending = ""
+
+	if c.prePrompt != nil {
+		c.prePrompt(c.session)
+	}
+	c.session.printNotes()
+
+	userInput, err := c.readLine(c.currentPrompt(), pending)
 	if err != nil {
 		return err
 	}
 
-	c.lr.AppendHistory(userInput)
+	for {
+		continued := continuesLine(userInput)
+		if !continued && !(c.continueQuotes && c.hasOpenQuote(userInput)) {
+			break
+		}
+
+		next, err := c.readLine(c.continuationPrompt, "")
+		if err != nil {
+			return err
+		}
+		if continued {
+			userInput = userInput[:len(userInput)-1] + next
+		} else {
+			userInput += "\n" + next
+		}
+	}
+
+	input, transformErr := c.TransformInput(userInput)
+	if transformErr == nil && !c.confirmInput(userInput, input) {
+		return nil
+	}
+
+	recorded := !c.excludedFromHistory(userInput) && c.appendHistory(c.redact(userInput))
+
+	if transformErr != nil {
+		err = c.failInput(transformErr)
+	} else {
+		err = c.runInput(input)
+	}
+	if recorded {
+		c.setHistoryStatus(c.lastFailed)
+	}
+	return err
+}
+
+// failInput records that input could not be run because of err, and returns it.
+func (c *GomCLI) failInput(err error) error {
+	c.lastFailed = true
+	c.session.setStatus(StatusFailed)
+	return err
+}
+
+// continuesLine reports whether line ends with an unescaped backslash.
+func continuesLine(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
 
-	return c.processInput(userInput)
+// hasOpenQuote reports whether line contains a quote that is not closed.
+func (c *GomCLI) hasOpenQuote(line string) bool {
+	var perr *ParseError
+	_, err := c.parse(line)
+	return errors.As(err, &perr) && perr.Reason == "unterminated quote"
+}
+
+// AddInputTransformer appends an InputTransformer to the ones applied, in the
+// order they were added, to the input entered at the prompt or run otherwise,
+// before parsing it. The history keeps the input as entered.
+func (c *GomCLI) AddInputTransformer(t InputTransformer) {
+	c.transformers = append(c.transformers, t)
+}
+
+// TransformInput returns input as rewritten by the InputTransformers, i.e. as
+// it would be parsed and run.
+func (c *GomCLI) TransformInput(input string) (string, error) {
+	for _, t := range c.transformers {
+		var err error
+		if input, err = t(c.session, input); err != nil {
+			return "", err
+		}
+	}
+	return input, nil
 }
 
 func (c *GomCLI) processInput(input string) error {
-	lines, err := splitInlineCommands(input)
+	input, err := c.TransformInput(input)
 	if err != nil {
-		return err
+		return c.failInput(err)
+	}
+	return c.runInput(input)
+}
+
+// runInput runs input already rewritten by the InputTransformers.
+func (c *GomCLI) runInput(input string) error {
+	c.lastFailed = false
+	lines, err := c.parse(input)
+	if err != nil {
+		return c.failInput(err)
 	}
 
 	for _, line := range lines {
@@ -210,109 +894,253 @@ func (c *GomCLI) processInput(input string) error {
 		if err != nil {
 			return err
 		}
+		if c.stopRequested() {
+			break
+		}
 	}
 
 	return nil
 }
 
-func (c *GomCLI) processLine(line string) error {
-	tokens, err := shlex.Split(line, true)
-	if err != nil {
-		return ErrCliCannotParseLine
+func (c *GomCLI) processLine(line ParsedLine) error {
+	if len(line.Tokens) == 0 {
+		return nil
 	}
 
-	if len(tokens) == 0 {
-		return nil
+	if c.expandVars {
+		line = c.expandLine(line)
+	}
+	if c.trace {
+		c.errorf("+ %v\n", c.traceLine(line))
 	}
 
-	for i := len(tokens); i > 0; i-- {
-		chunk := strings.Join(tokens[:i], " ")
-		cmd, err := c.getCommand(chunk)
-		if err != nil {
-			continue
+	if c.assignments {
+		var assigns []Token
+		assigns, line = splitAssignments(line)
+		if len(assigns) > 0 {
+			if err := c.checkAssignments(); err != nil {
+				return c.failLine(err)
+			}
+		}
+		if len(line.Tokens) == 0 {
+			for _, tok := range assigns {
+				name, value, _ := splitAssignment(tok.Value)
+				c.session.SetVar(name, value)
+			}
+			return nil
+		}
+		if len(assigns) > 0 {
+			defer c.session.assign(assigns)()
 		}
+	}
 
-		if len(tokens) > 1 {
-			err = cmd.execute(tokens[i:]...)
-		} else {
-			err = cmd.execute()
+	if len(line.Pipes) > 0 {
+		return c.processPipeline(line)
+	}
+	return c.dispatch(line)
+}
+
+// dispatch runs the Command invoked by line, or the NotFoundHandler if there is
+// none.
+func (c *GomCLI) dispatch(line ParsedLine) error {
+	if c.wantsPartialHelp(line) {
+		return c.partialLineHelp(c.session, line)
+	}
+
+	tokens := line.Values()
+
+	if cmd, i := c.lookupCommand(tokens); cmd != nil {
+		cmd, err := c.resolveRedirect(cmd)
+		if errors.Is(err, ErrCliRedirectCycle) {
+			return c.failLine(err)
 		}
+		if err == nil {
+			c.session.line = line
+			if err = c.checkRestrictions(cmd); err != nil {
+				err = cmd.handleErr(err, tokens[i:])
+			} else {
+				err = c.capture(cmd, func() error {
+					if len(tokens) > 1 {
+						return c.execute(cmd, tokens[i:]...)
+					}
+					return c.execute(cmd)
+				})
+			}
+			if errors.Is(err, ErrCliExitRequested) {
+				c.Stop()
+				return nil
+			}
 
-		if err != nil && c.exitOnCmdError {
-			return err
+			c.lastFailed = c.lastFailed || err != nil
+			c.session.setStatus(exitStatus(err))
+			if err != nil && c.exitOnCmdError {
+				return err
+			}
+			return nil
 		}
-		return nil
 	}
 
+	c.lastFailed = true
+	c.session.setStatus(StatusNotFound)
+	if c.suggestionHandler != nil {
+		return c.suggestionHandler(tokens[0], c.Suggest(tokens))
+	}
 	if c.notFoundHandler != nil {
-		err = c.notFoundHandler(tokens[0])
+		return c.notFoundHandler(tokens[0])
 	}
-	return err
+	return nil
 }
 
-func splitInlineCommands(userInput string) ([]string, error) {
-	parsed, err := shlex.Split(userInput, false)
-	if err != nil {
-		return nil, ErrCliCannotParseLine
+func (c *GomCLI) execute(cmd *Command, args ...string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr := &PanicError{Cmd: cmd.Name, Value: r, Stack: debug.Stack()}
+			if c.panicHandler != nil {
+				err = c.panicHandler(cmd, perr)
+			} else {
+				err = cmd.handleErr(perr, args)
+			}
+		}
+	}()
+
+	if cmd.Streaming || cmd.wantsContext() {
+		stop := c.session.interruptible()
+		defer stop()
 	}
 
-	lines := []string{}
-	command := []string{}
+	if c.slowThreshold > 0 && !cmd.Streaming {
+		defer c.warnIfSlow(cmd.Name, time.Now())
+	}
 
-	for _, token := range parsed {
-		if len(token) > 1 && token[len(token)-2:] == "\\;" {
-			command = append(command, token)
-		} else if len(token) > 1 && token[len(token)-2:] == ";;" {
-			return nil, ErrCliCannotParseLine
-		} else if token[len(token)-1:] == ";" {
-			if token[:len(token)-1] != "" {
-				command = append(command, token[:len(token)-1])
-			}
-			lines = append(lines, strings.Join(command, " "))
-			command = []string{}
-		} else {
-			command = append(command, token)
+	for _, rw := range c.rewriters {
+		rewritten, err := rw(cmd, args)
+		if err != nil {
+			return cmd.handleErr(err, args)
 		}
+		args = rewritten
 	}
 
-	if len(command) > 0 {
-		lines = append(lines, strings.Join(command, " "))
+	exec := func(s *Session, cmd *Command, args []string) error {
+		return cmd.execute(s, args...)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		exec = c.middlewares[i](exec)
 	}
+	return exec(c.session, cmd, args)
+}
 
-	return lines, nil
+func (c *GomCLI) warnIfSlow(name string, start time.Time) {
+	elapsed := time.Since(start)
+	if elapsed < c.slowThreshold {
+		return
+	}
+
+	elapsed = elapsed.Round(time.Millisecond)
+	c.errorf("[i] Command %q took %v\n", name, elapsed)
+	if c.slowLogger != nil {
+		c.slowLogger.Printf("slow command %q took %v", name, elapsed)
+	}
+}
+
+// RunLine runs input as if it was entered at the prompt, without adding it to
+// the history, and returns the error that Start would return for it.
+func (c *GomCLI) RunLine(input string) error {
+	return c.processInput(input)
 }
 
 // StartWithInput starts the CLI by providing initial input that will
 // be split into lines and, if applicable, into commands.
 func (c *GomCLI) StartWithInput(input string) error {
+	c.detectWorkspace()
 	if err := c.processInput(input); err != nil {
 		return err
 	}
+	if c.stopRequested() {
+		atomic.StoreInt32(&c.stopped, 0)
+		c.Close()
+		return nil
+	}
 
 	return c.Start()
 }
 
 // Start starts the CLI, iteratively displaying the prompt and handling
-// user input until Close is called or an error is returned during user input
-// processing.
+// user input until Stop or Close is called or an error is returned during user
+// input processing. The WorkspaceDetectors are run before the first prompt.
+//
+// If the standard input is not a terminal, e.g. when commands are piped into
+// the program, no prompt is displayed and the input is run as a script by
+// RunReader instead, so that Start returns nil once it ends successfully.
 func (c *GomCLI) Start() error {
 	defer c.Close()
+	c.detectWorkspace()
 
-	for {
+	defer atomic.StoreInt32(&c.stopped, 0)
+	if c.batchInput != nil {
+		return c.run("stdin", c.batchInput)
+	}
+
+	for !c.stopRequested() {
 		if err := c.process(); err != nil {
 			switch err {
-			case liner.ErrPromptAborted:
+			case errPromptAborted:
 				return ErrCliPromptAborted
 			default:
 				return err
 			}
 		}
 	}
+	return nil
+}
+
+// Stop makes Start return nil once the input being processed, if any, has been
+// run, skipping the rest of its commands, e.g. from an "exit" Command. It can
+// be called from any goroutine, but does not interrupt a prompt waiting for
+// input.
+func (c *GomCLI) Stop() {
+	atomic.StoreInt32(&c.stopped, 1)
+}
+
+func (c *GomCLI) stopRequested() bool {
+	return atomic.LoadInt32(&c.stopped) != 0
+}
+
+// OnClose registers a function to be called when the CLI is closed, e.g. to
+// release the resources of the application. The functions are called in the
+// reverse order they were registered, and the first error returned is
+// returned by Close.
+func (c *GomCLI) OnClose(fn func() error) {
+	c.closeHooks = append(c.closeHooks, fn)
 }
 
 // Close stops the CLI processing, updating the history file if applicable and
-// resetting the terminal into its previous mode.
-func (c *GomCLI) Close() {
-	c.writeHistory()
-	c.lr.Close()
+// resetting the terminal into its previous mode. It returns the first error
+// from the OnClose functions, saving the history or resetting the terminal.
+// Only the first call closes the CLI, the following ones return the same
+// error. The terminal is reset even if any step panics.
+func (c *GomCLI) Close() error {
+	c.closeOnce.Do(func() {
+		c.closeErr = c.close()
+	})
+	return c.closeErr
+}
+
+func (c *GomCLI) close() (err error) {
+	defer func() {
+		if lrErr := c.lr.Close(); err == nil {
+			err = lrErr
+		}
+	}()
+
+	for i := len(c.closeHooks) - 1; i >= 0; i-- {
+		if hookErr := c.closeHooks[i](); err == nil {
+			err = hookErr
+		}
+	}
+	c.session.close()
+	c.forgetCredentials()
+	if histErr := c.closeHistory(); err == nil {
+		err = histErr
+	}
+	return err
 }
@@ -0,0 +1,70 @@
+// Package gomclitest provides a harness to test gomcli applications without a
+// terminal, by feeding them scripted lines and capturing what they do.
+package gomclitest
+
+import (
+	"io"
+	"strings"
+
+	"github.com/jmreyes/gomcli"
+)
+
+// Call records the invocation of a Command, with the arguments it received.
+type Call struct {
+	Name string
+	Args []string
+}
+
+// Result holds what happened when running a line: the output printed, the
+// error returned, and the Commands invoked.
+type Result struct {
+	Line   string
+	Output string
+	Err    error
+	Calls  []Call
+}
+
+// Harness drives a GomCLI created with gomcli.NewWithIO. Errors returned by
+// Commands are reported in the Result of their line, as SetExitOnCmdError is
+// enabled, and so is a *gomcli.CommandNotFoundError for lines that do not
+// match any Command, unless the NotFoundHandler is replaced.
+type Harness struct {
+	CLI   *gomcli.GomCLI
+	calls []Call
+}
+
+// New returns a Harness with a new GomCLI, to be set up with Commands through
+// the CLI field before calling Run.
+func New() *Harness {
+	h := &Harness{CLI: gomcli.NewWithIO(strings.NewReader(""), io.Discard)}
+	h.CLI.SetExitOnCmdError(true)
+	h.CLI.SetNotFoundHandler(func(name string) error {
+		return &gomcli.CommandNotFoundError{Name: name}
+	})
+	h.CLI.Use(func(next gomcli.ExecFunc) gomcli.ExecFunc {
+		return func(s *gomcli.Session, cmd *gomcli.Command, args []string) error {
+			h.calls = append(h.calls, Call{Name: cmd.Name, Args: append([]string(nil), args...)})
+			return next(s, cmd, args)
+		}
+	})
+	return h
+}
+
+// Run runs each of lines in order, as if entered at the prompt, and returns
+// a Result for each.
+func (h *Harness) Run(lines ...string) []Result {
+	results := make([]Result, len(lines))
+	for i, line := range lines {
+		h.calls = nil
+		out, err := h.CLI.Session().Capture(func() error {
+			return h.CLI.RunLine(line)
+		})
+		results[i] = Result{Line: line, Output: out, Err: err, Calls: h.calls}
+	}
+	return results
+}
+
+// Close closes the GomCLI, returning the error from GomCLI.Close.
+func (h *Harness) Close() error {
+	return h.CLI.Close()
+}
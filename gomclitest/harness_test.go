@@ -0,0 +1,65 @@
+package gomclitest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jmreyes/gomcli"
+)
+
+func newHarness() *Harness {
+	h := New()
+	h.CLI.AddCommand(gomcli.Command{Name: "greet", Function: func(s *gomcli.Session, name string) {
+		s.Printf("Hello, %v\n", name)
+	}})
+	h.CLI.AddCommand(gomcli.Command{Name: "fail", Function: func() error {
+		return errors.New("failed")
+	}})
+	return h
+}
+
+func TestRunOutput(t *testing.T) {
+	h := newHarness()
+	defer h.Close()
+
+	results := h.Run("greet bob", "greet alice")
+	for i, want := range []string{"Hello, bob\n", "Hello, alice\n"} {
+		if results[i].Output != want || results[i].Err != nil {
+			t.Errorf("Run(%q) = %q, %v, want %q", results[i].Line, results[i].Output, results[i].Err, want)
+		}
+	}
+}
+
+func TestRunCalls(t *testing.T) {
+	h := newHarness()
+	defer h.Close()
+
+	results := h.Run("greet bob", "fail")
+	if want := []Call{{Name: "greet", Args: []string{"bob"}}}; !reflect.DeepEqual(results[0].Calls, want) {
+		t.Errorf("Calls = %v, want %v", results[0].Calls, want)
+	}
+	if calls := results[1].Calls; len(calls) != 1 || calls[0].Name != "fail" || len(calls[0].Args) != 0 {
+		t.Errorf("Calls = %v, want a single call to fail", calls)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	h := newHarness()
+	defer h.Close()
+
+	results := h.Run("fail", "nope", "greet bob")
+	if results[0].Err == nil || results[0].Err.Error() != "failed" {
+		t.Errorf("Err = %v, want %q", results[0].Err, "failed")
+	}
+	var notFound *gomcli.CommandNotFoundError
+	if !errors.As(results[1].Err, &notFound) || notFound.Name != "nope" {
+		t.Errorf("Err = %v, want a CommandNotFoundError for %q", results[1].Err, "nope")
+	}
+	if len(results[1].Calls) != 0 {
+		t.Errorf("Calls = %v, want none", results[1].Calls)
+	}
+	if results[2].Err != nil {
+		t.Errorf("Err = %v after a failed line", results[2].Err)
+	}
+}
@@ -0,0 +1,386 @@
+package gomcli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrGrammarInvalid is wrapped by the error returned from ParseGrammar when the
+// spec is malformed.
+var ErrGrammarInvalid = errors.New("Invalid grammar")
+
+// GrammarArgs holds the values matched by a Grammar. Placeholders and named
+// choices map to their values, converted to the type of the placeholder, and
+// the literal words that were present map to true.
+type GrammarArgs map[string]interface{}
+
+// Grammar describes the syntax of a Command as a sequence of literal words,
+// choices such as {up|down}, optionally named as in {state:up|down},
+// placeholders such as <name> or <mtu:int>, and optional groups in brackets,
+// e.g. "interface <name> {state:up|down} [mtu <mtu:int>]". The leading literal
+// words make up the name of the Command. Placeholder types are string, the
+// default, int, uint, float, bool, duration and time.
+type Grammar struct {
+	name  string
+	spec  string
+	nodes []grammarNode
+}
+
+type grammarKind int
+
+const (
+	grammarLiteral grammarKind = iota
+	grammarChoice
+	grammarPlaceholder
+	grammarOptional
+)
+
+type grammarNode struct {
+	kind     grammarKind
+	name     string
+	words    []string
+	typ      reflect.Type
+	raw      string
+	children []grammarNode
+}
+
+var grammarTypes = map[string]reflect.Type{
+	"string":   reflect.TypeOf(""),
+	"int":      reflect.TypeOf(0),
+	"uint":     reflect.TypeOf(uint(0)),
+	"float":    reflect.TypeOf(0.0),
+	"bool":     reflect.TypeOf(false),
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"time":     reflect.TypeOf(time.Time{}),
+}
+
+// ParseGrammar parses spec into a Grammar.
+func ParseGrammar(spec string) (*Grammar, error) {
+	items, err := lexGrammar(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, rest, err := parseGrammarSeq(items)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: unexpected %q in %q", ErrGrammarInvalid, rest[0], spec)
+	}
+
+	var name []string
+	for len(nodes) > 0 && nodes[0].kind == grammarLiteral {
+		name = append(name, nodes[0].name)
+		nodes = nodes[1:]
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf("%w: %q does not start with a name", ErrGrammarInvalid, spec)
+	}
+
+	return &Grammar{name: strings.Join(name, " "), spec: spec, nodes: nodes}, nil
+}
+
+// lexGrammar splits spec into words, brackets, choices and placeholders.
+func lexGrammar(spec string) ([]string, error) {
+	var items []string
+	for i := 0; i < len(spec); {
+		switch ch := spec[i]; {
+		case isSpace(ch):
+			i++
+		case ch == '[' || ch == ']':
+			items = append(items, spec[i:i+1])
+			i++
+		case ch == '{' || ch == '<':
+			closing := byte('}')
+			if ch == '<' {
+				closing = '>'
+			}
+			end := strings.IndexByte(spec[i:], closing)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated %q in %q", ErrGrammarInvalid, ch, spec)
+			}
+			items = append(items, spec[i:i+end+1])
+			i += end + 1
+		default:
+			end := strings.IndexAny(spec[i:], " \t\n[]{}<>")
+			if end < 0 {
+				end = len(spec) - i
+			}
+			items = append(items, spec[i:i+end])
+			i += end
+		}
+	}
+	return items, nil
+}
+
+// parseGrammarSeq parses items up to the end of the current group, returning
+// the items that follow it.
+func parseGrammarSeq(items []string) ([]grammarNode, []string, error) {
+	var nodes []grammarNode
+	for len(items) > 0 {
+		item := items[0]
+		items = items[1:]
+
+		switch {
+		case item == "]":
+			return nodes, append([]string{item}, items...), nil
+		case item == "[":
+			children, rest, err := parseGrammarSeq(items)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(rest) == 0 || len(children) == 0 {
+				return nil, nil, fmt.Errorf("%w: empty or unterminated optional group", ErrGrammarInvalid)
+			}
+			nodes = append(nodes, grammarNode{kind: grammarOptional, children: children})
+			items = rest[1:]
+		case item[0] == '{':
+			name, body := "", item[1:len(item)-1]
+			if i := strings.IndexByte(body, ':'); i >= 0 {
+				name, body = body[:i], body[i+1:]
+			}
+			words := strings.Split(body, "|")
+			for _, w := range words {
+				if w == "" {
+					return nil, nil, fmt.Errorf("%w: empty choice in %q", ErrGrammarInvalid, item)
+				}
+			}
+			nodes = append(nodes, grammarNode{kind: grammarChoice, name: name, words: words})
+		case item[0] == '<':
+			name, typName := item[1:len(item)-1], "string"
+			if i := strings.IndexByte(name, ':'); i >= 0 {
+				name, typName = name[:i], name[i+1:]
+			}
+			typ, ok := grammarTypes[typName]
+			if !ok || name == "" {
+				return nil, nil, fmt.Errorf("%w: invalid placeholder %q", ErrGrammarInvalid, item)
+			}
+			nodes = append(nodes, grammarNode{kind: grammarPlaceholder, name: name, typ: typ, raw: item})
+		default:
+			nodes = append(nodes, grammarNode{kind: grammarLiteral, name: item})
+		}
+	}
+	return nodes, nil, nil
+}
+
+// Name returns the name of the Command described by the Grammar.
+func (g *Grammar) Name() string {
+	return g.name
+}
+
+// Command returns a Command that matches its arguments against the Grammar,
+// and calls fn with the values matched. Its usage and completion are derived
+// from the Grammar as well.
+func (g *Grammar) Command(fn func(*Session, GrammarArgs) error) Command {
+	return Command{
+		Name:      g.name,
+		Usage:     g.spec,
+		Completer: g.complete,
+		grammar:   g,
+		typed: func(s *Session, args []string) error {
+			values, err := g.Match(s.cli, args)
+			if err != nil {
+				return err
+			}
+			return fn(s, values)
+		},
+	}
+}
+
+// Match matches args, the arguments following the name of the Command,
+// against the Grammar, converting the placeholders with the conversions of c.
+// If they do not match, an *ArgumentError is returned for the first argument
+// that could not be matched.
+func (g *Grammar) Match(c *GomCLI, args []string) (GrammarArgs, error) {
+	m := grammarMatcher{cli: c, args: args}
+	values := GrammarArgs{}
+	if m.match(g.nodes, 0, values) {
+		return values, nil
+	}
+
+	if m.fail >= len(args) {
+		return nil, &ArgumentError{Cmd: g.name, Index: m.fail, Err: ErrCmdMissingArgs}
+	}
+	return nil, &ArgumentError{
+		Cmd:   g.name,
+		Index: m.fail,
+		Token: args[m.fail],
+		Err:   ErrCmdInvalidArgs,
+	}
+}
+
+// grammarMatcher matches arguments with backtracking, keeping track of the
+// furthest argument that failed to match.
+type grammarMatcher struct {
+	cli  *GomCLI
+	args []string
+	fail int
+}
+
+func (m *grammarMatcher) match(nodes []grammarNode, pos int, values GrammarArgs) bool {
+	if len(nodes) == 0 {
+		if pos < len(m.args) {
+			m.failAt(pos)
+			return false
+		}
+		return true
+	}
+
+	node, rest := nodes[0], nodes[1:]
+	if node.kind == grammarOptional {
+		attempt := make(GrammarArgs, len(values))
+		for k, v := range values {
+			attempt[k] = v
+		}
+		seq := append(append([]grammarNode(nil), node.children...), rest...)
+		if m.match(seq, pos, attempt) {
+			for k, v := range attempt {
+				values[k] = v
+			}
+			return true
+		}
+		return m.match(rest, pos, values)
+	}
+
+	if pos >= len(m.args) {
+		m.failAt(pos)
+		return false
+	}
+	value, ok := m.accept(node, m.args[pos])
+	if !ok {
+		m.failAt(pos)
+		return false
+	}
+	if value != nil {
+		values[node.name] = value
+	}
+
+	if !m.match(rest, pos+1, values) {
+		delete(values, node.name)
+		return false
+	}
+	return true
+}
+
+// accept reports whether arg matches node, returning the value for node, if
+// any.
+func (m *grammarMatcher) accept(node grammarNode, arg string) (interface{}, bool) {
+	switch node.kind {
+	case grammarLiteral:
+		return true, arg == node.name
+	case grammarChoice:
+		for _, w := range node.words {
+			if w == arg {
+				if node.name == "" {
+					return nil, true
+				}
+				return arg, true
+			}
+		}
+	case grammarPlaceholder:
+		v, err := m.cli.convertArg(node.typ, arg)
+		if err == nil {
+			return v.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// expect collects in items the elements of nodes that can follow the arguments
+// from pos on, including "<cr>" if the arguments can end there.
+func (m *grammarMatcher) expect(nodes []grammarNode, pos int, items map[string]bool) {
+	if pos == len(m.args) {
+		next, end := firstGrammarItems(nodes)
+		for _, item := range next {
+			items[item] = true
+		}
+		if end {
+			items["<cr>"] = true
+		}
+		return
+	}
+	if len(nodes) == 0 {
+		return
+	}
+
+	node, rest := nodes[0], nodes[1:]
+	if node.kind == grammarOptional {
+		m.expect(append(append([]grammarNode(nil), node.children...), rest...), pos, items)
+		m.expect(rest, pos, items)
+		return
+	}
+	if _, ok := m.accept(node, m.args[pos]); ok {
+		m.expect(rest, pos+1, items)
+	}
+}
+
+// next returns the elements of the Grammar that can follow args, sorted, with
+// "<cr>" standing for the end of the input.
+func (g *Grammar) next(c *GomCLI, args []string) []string {
+	m := grammarMatcher{cli: c, args: args}
+	set := make(map[string]bool)
+	m.expect(g.nodes, 0, set)
+
+	items := make([]string, 0, len(set))
+	for item := range set {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return items
+}
+
+func (m *grammarMatcher) failAt(pos int) {
+	if pos > m.fail {
+		m.fail = pos
+	}
+}
+
+// complete returns the words that can follow the name of the Command starting
+// with prefix.
+func (g *Grammar) complete(prefix string) []string {
+	var res []string
+	items, _ := firstGrammarItems(g.nodes)
+	for _, item := range items {
+		if !strings.HasPrefix(item, "<") && strings.HasPrefix(item, prefix) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// completeAt returns the literal words that can follow args, starting with
+// prefix.
+func (g *Grammar) completeAt(c *GomCLI, args []string, prefix string) []string {
+	var res []string
+	for _, item := range g.next(c, args) {
+		if !strings.HasPrefix(item, "<") && strings.HasPrefix(item, prefix) {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// firstGrammarItems returns the literal words, choices and placeholders that
+// can appear first in nodes, and whether nodes can match no arguments at all.
+func firstGrammarItems(nodes []grammarNode) ([]string, bool) {
+	var items []string
+	for _, node := range nodes {
+		switch node.kind {
+		case grammarLiteral:
+			return append(items, node.name), false
+		case grammarChoice:
+			return append(items, node.words...), false
+		case grammarPlaceholder:
+			return append(items, node.raw), false
+		case grammarOptional:
+			sub, _ := firstGrammarItems(node.children)
+			items = append(items, sub...)
+		}
+	}
+	return items, true
+}
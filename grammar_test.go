@@ -0,0 +1,32 @@
+package gomcli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestGrammarCompletesAtCursor(t *testing.T) {
+	g, err := ParseGrammar("interface <name> {state:up|down} [mtu <mtu:int>]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.AddCommand(g.Command(func(*Session, GrammarArgs) error { return nil }))
+
+	for _, tc := range []struct {
+		line string
+		head string
+		comp []string
+	}{
+		{"interface eth0 ", "interface eth0 ", []string{"down", "up"}},
+		{"interface eth0 u", "interface eth0 ", []string{"up"}},
+		{"interface eth0 up ", "interface eth0 up ", []string{"mtu"}},
+		{"interface eth0 up mtu ", "interface eth0 up mtu ", nil},
+	} {
+		head, comp, _ := c.complete(tc.line, len(tc.line))
+		if head != tc.head || !reflect.DeepEqual(comp, tc.comp) {
+			t.Errorf("complete(%q) = %q, %q, want %q, %q", tc.line, head, comp, tc.head, tc.comp)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package gomcli
+
+import (
+	"flag"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// EnableHelp registers a built-in "help" Command, which lists the registered
+// Commands along with their Help, or prints the Help and usage of a specific
+// Command when given its name. The usage is taken from Command.Usage or, if not
+// set, derived from the signature of its Function. Input ending with an unquoted
+// "?" word, e.g. "deploy ?", then prints what can be typed next instead of
+// being executed.
+func (c *GomCLI) EnableHelp() {
+	c.partialHelp = true
+	c.AddCommand(Command{
+		Name:      "help",
+		Help:      "Show available commands, or help for a given command",
+		Usage:     "help [command]",
+		Completer: c.helpCompleter,
+		builtin:   c.help,
+	})
+}
+
+func (c *GomCLI) help(s *Session, args []string) error {
+	if len(args) == 0 {
+		names := c.contextualComplete()
+		sort.Strings(names)
+
+		s.outMu.Lock()
+		defer s.outMu.Unlock()
+		w := tabwriter.NewWriter(s.out, 0, 4, 2, ' ', 0)
+		for _, name := range names {
+			cmd := c.commands[name]
+			w.Write([]byte(name + "\t" + cmd.Help + "\n"))
+		}
+		return w.Flush()
+	}
+
+	name := strings.Join(args, " ")
+	cmd, err := c.getCommand(name)
+	if err != nil {
+		s.Printf("No help for unknown command %q\n", name)
+		return nil
+	}
+
+	s.Printf("Usage: %v\n", cmd.usage())
+	if cmd.Help != "" {
+		s.Printf("\n%v\n", cmd.Help)
+	}
+	for i, arg := range cmd.Args {
+		if i == 0 {
+			s.Printf("\nArguments:\n")
+		}
+		if len(arg.Choices) > 0 {
+			s.Printf("  %v  one of: %v\n", arg.Name, strings.Join(arg.Choices, ", "))
+		} else {
+			s.Printf("  %v\n", arg.Name)
+		}
+	}
+	if cmd.Flags != nil {
+		s.Printf("\nFlags:\n")
+		s.outMu.Lock()
+		defer s.outMu.Unlock()
+		prev := cmd.Flags.Output()
+		cmd.Flags.SetOutput(s.out)
+		cmd.Flags.PrintDefaults()
+		cmd.Flags.SetOutput(prev)
+	}
+	return nil
+}
+
+// wantsPartialHelp reports whether line asks for partial-line help.
+func (c *GomCLI) wantsPartialHelp(line ParsedLine) bool {
+	n := len(line.Tokens)
+	return c.partialHelp && n > 0 && line.Tokens[n-1].Raw == "?"
+}
+
+// partialLineHelp prints the subcommands, arguments and flags that can follow the
+// input of line before its final "?", with the same metadata used for
+// completion.
+func (c *GomCLI) partialLineHelp(s *Session, line ParsedLine) error {
+	tokens := line.Values()
+	tokens = tokens[:len(tokens)-1]
+	prefix := strings.Join(tokens, " ")
+
+	var rows [][2]string
+	seen := make(map[string]bool)
+	for _, name := range c.contextualComplete() {
+		if prefix != "" && !strings.HasPrefix(name, prefix+" ") {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		word := strings.Fields(rest)[0]
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		help := ""
+		if strings.TrimSpace(rest) == word {
+			help = c.commands[name].Help
+		}
+		rows = append(rows, [2]string{word, help})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	if cmd, i := c.lookupCommand(tokens); cmd != nil {
+		rows = append(rows, c.expectedArgs(cmd, tokens[i:])...)
+	}
+
+	if len(rows) == 0 {
+		s.Printf("No help for unknown command %q\n", prefix)
+		return nil
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	w := tabwriter.NewWriter(s.out, 0, 4, 2, ' ', 0)
+	for _, row := range rows {
+		w.Write([]byte("  " + row[0] + "\t" + row[1] + "\n"))
+	}
+	return w.Flush()
+}
+
+// expectedArgs describes what can follow args for cmd, as rows of an item and
+// its description, where "<cr>" stands for running the Command as is.
+func (c *GomCLI) expectedArgs(cmd *Command, args []string) (rows [][2]string) {
+	if cmd.grammar != nil {
+		for _, item := range cmd.grammar.next(c, args) {
+			rows = append(rows, [2]string{item, ""})
+		}
+		return
+	}
+
+	if n := len(args); n < len(cmd.ArgCompleters) && cmd.ArgCompleters[n] != nil {
+		for _, word := range cmd.ArgCompleters[n]("") {
+			rows = append(rows, [2]string{word, ""})
+		}
+	}
+
+	if len(args) == 0 {
+		if cmd.Flags != nil {
+			cmd.Flags.VisitAll(func(f *flag.Flag) {
+				rows = append(rows, [2]string{"-" + f.Name, f.Usage})
+			})
+		}
+		if len(cmd.Args) == 0 {
+			for _, comp := range cmd.completions("") {
+				rows = append(rows, [2]string{comp.Text, comp.Description})
+			}
+		}
+	}
+
+	if len(args) < len(cmd.Args) {
+		arg := cmd.Args[len(args)]
+		desc := ""
+		if len(arg.Choices) > 0 {
+			desc = "one of: " + strings.Join(arg.Choices, ", ")
+		}
+		return append(rows, [2]string{"<" + arg.Name + ">", desc})
+	}
+
+	parts := strings.Fields(cmd.usage())
+	if n := len(strings.Fields(cmd.Name)); len(parts) >= n {
+		parts = parts[n:]
+	}
+	if len(parts) > 0 && parts[0] == "[flags]" {
+		parts = parts[1:]
+	}
+	switch {
+	case len(args) < len(parts):
+		rows = append(rows, [2]string{parts[len(args)], ""})
+		if strings.HasPrefix(parts[len(args)], "[") {
+			rows = append(rows, [2]string{"<cr>", ""})
+		}
+	case len(parts) > 0 && strings.HasSuffix(parts[len(parts)-1], "..."):
+		rows = append(rows, [2]string{parts[len(parts)-1], ""}, [2]string{"<cr>", ""})
+	default:
+		rows = append(rows, [2]string{"<cr>", ""})
+	}
+	return
+}
+
+func (c *GomCLI) helpCompleter(text string) (res []string) {
+	for _, name := range c.contextualComplete() {
+		if strings.HasPrefix(name, text) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return
+}
+
+// usage returns the Usage of the Command, or a usage string derived from the
+// parameters of its Function.
+func (c *Command) usage() string {
+	if c.Usage != "" {
+		return c.Usage
+	}
+
+	if c.typed != nil {
+		parts := []string{c.Name}
+		for _, t := range c.signature {
+			parts = append(parts, "<"+t.String()+">")
+		}
+		return strings.Join(parts, " ")
+	}
+
+	if c.Function == nil || c.builtin != nil {
+		return c.Name
+	}
+
+	t := reflect.TypeOf(c.Function)
+	if t.Kind() != reflect.Func {
+		return c.Name
+	}
+
+	parts := []string{c.Name}
+	if c.Flags != nil {
+		parts = append(parts, "[flags]")
+	}
+	if len(c.Args) > 0 {
+		for _, arg := range c.Args {
+			parts = append(parts, "<"+arg.Name+">")
+		}
+		return strings.Join(parts, " ")
+	}
+	if argTypes := functionArgs(t); isStructBinding(argTypes) {
+		for _, f := range structFields(argTypes[0].Elem()) {
+			if f.required {
+				parts = append(parts, "<"+f.name+">")
+			} else {
+				parts = append(parts, "["+f.name+"]")
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	injected := true
+	for i := 0; i < t.NumIn(); i++ {
+		if injected = injected && isInjected(t.In(i)); injected {
+			continue
+		}
+		if i == t.NumIn()-1 && (t.IsVariadic() || t.In(i).Kind() == reflect.Slice) {
+			parts = append(parts, "<"+t.In(i).Elem().String()+">...")
+			continue
+		}
+		parts = append(parts, "<"+t.In(i).String()+">")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,494 @@
+package gomcli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultHistoryLimit = 1000
+
+// HistoryStatus tells whether the input of a HistoryEntry was run successfully.
+type HistoryStatus int
+
+const (
+	// HistoryUnknown is the status of entries seeded or read from a plain
+	// history file.
+	HistoryUnknown HistoryStatus = iota
+	// HistorySucceeded is the status of input run without errors.
+	HistorySucceeded
+	// HistoryFailed is the status of input that could not be parsed, did not
+	// match any Command, or whose Command returned an error not handled by its
+	// ErrHandler.
+	HistoryFailed
+)
+
+// HistoryEntry is an entry of the history. Time is zero when unknown.
+type HistoryEntry struct {
+	Line   string
+	Time   time.Time
+	Status HistoryStatus
+}
+
+// HistoryStore persists the history across sessions. Load is called when the
+// store is set, to obtain the history of previous sessions. Append is called
+// with each entry added during the session, once run, and Save with the entries
+// that replace the whole stored history, e.g. when it is cleared. If the store
+// implements io.Closer, Close is called when the CLI is closed or the store is
+// replaced.
+type HistoryStore interface {
+	Load() ([]HistoryEntry, error)
+	Append(entry HistoryEntry) error
+	Save(history []HistoryEntry) error
+}
+
+// extendedHistoryEntry matches the entries of the extended history file
+// format, e.g. ": 1700000000:0;command", where the status is 0 for success, 1
+// for failure and - if unknown.
+var extendedHistoryEntry = regexp.MustCompile(`^: (\d+):([01-]);`)
+
+// SetHistoryLimit sets the maximum number of entries kept in the history, in
+// memory and in the history file, dropping the oldest ones when exceeded. A
+// limit of 0 or less means unlimited. The default is 1000. Note that history
+// navigation at the prompt is limited to the latest 1000 entries by Liner.
+func (c *GomCLI) SetHistoryLimit(n int) {
+	c.historyLimit = n
+	if c.trimHistory() {
+		c.syncHistory()
+	}
+}
+
+// SetHistoryIgnoreDups sets whether an entry identical to the previous one is
+// left out of the history. The default is true.
+func (c *GomCLI) SetHistoryIgnoreDups(value bool) {
+	c.histIgnoreDups = value
+}
+
+// SetHistoryIgnoreSpace sets whether input starting with a space is left out of
+// the history. The default is false.
+func (c *GomCLI) SetHistoryIgnoreSpace(value bool) {
+	c.histIgnoreSpace = value
+}
+
+// SetHistoryExtended sets whether the history file is written in the extended
+// format, which records when each entry was run and whether it succeeded. The
+// default is false, and both formats are recognized when reading the file.
+func (c *GomCLI) SetHistoryExtended(value bool) {
+	c.histExtended = value
+}
+
+// SetHistoryEraseDups sets whether older duplicates of an entry are removed
+// from the history file when writing it. The default is false.
+func (c *GomCLI) SetHistoryEraseDups(value bool) {
+	c.histEraseDups = value
+}
+
+// SetHistoryErrorHandler sets the function called with the errors loading or
+// saving the history through the HistoryStore, e.g. when the history file
+// cannot be written. By default, a warning is printed. The function can call
+// SetHistoryStore(nil) to disable persistence for the rest of the session.
+func (c *GomCLI) SetHistoryErrorHandler(fn func(err error)) {
+	c.histErrHandler = fn
+}
+
+// historyError reports err, if not nil, to the history error handler, and
+// returns it.
+func (c *GomCLI) historyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if c.histErrHandler != nil {
+		c.histErrHandler(err)
+	} else {
+		c.errorf("[!] History: %v\n", err)
+	}
+	return err
+}
+
+// History returns a copy of the entries of the history, oldest first.
+func (c *GomCLI) History() []string {
+	lines := make([]string, len(c.history))
+	for i, entry := range c.history {
+		lines[i] = entry.Line
+	}
+	return lines
+}
+
+// HistoryEntries returns a copy of the entries of the history with the time
+// they were run and their status, oldest first.
+func (c *GomCLI) HistoryEntries() []HistoryEntry {
+	return append([]HistoryEntry(nil), c.history...)
+}
+
+// ClearHistory removes all the entries of the history, from the HistoryStore
+// as well if set.
+func (c *GomCLI) ClearHistory() {
+	c.history = nil
+	c.histPending = false
+	c.lr.ClearHistory()
+	if c.histStore != nil {
+		c.historyError(c.histStore.Save(nil))
+	}
+}
+
+// AppendHistory adds line as the latest entry of the history, regardless of
+// the settings that leave input out of it, so that applications can seed it.
+func (c *GomCLI) AppendHistory(line string) {
+	c.flushHistory()
+	entry := HistoryEntry{Line: line}
+	c.appendEntry(entry)
+	if c.histStore != nil {
+		c.historyError(c.histStore.Append(entry))
+	}
+}
+
+func (c *GomCLI) appendEntry(entry HistoryEntry) {
+	c.history = append(c.history, entry)
+	if c.trimHistory() {
+		c.syncHistory()
+		return
+	}
+	c.lr.AppendHistory(entry.Line)
+}
+
+// SetHistoryStore sets the HistoryStore the history is loaded from and saved
+// to, in place of the history file. The entries of the store come before the
+// ones already in the history, which are appended to the store. A nil store
+// disables persistence.
+func (c *GomCLI) SetHistoryStore(store HistoryStore) {
+	c.closeHistory()
+	c.histStore = store
+	if store == nil {
+		return
+	}
+
+	loaded, err := store.Load()
+	c.historyError(err)
+	for _, entry := range c.history {
+		if c.historyError(store.Append(entry)) != nil {
+			break
+		}
+	}
+	c.history = append(loaded, c.history...)
+	c.trimHistory()
+	c.syncHistory()
+}
+
+// excludedFromHistory reports whether input invokes any NoHistory Command,
+// directly or through a Redirect, or exceeds the input limits.
+func (c *GomCLI) excludedFromHistory(input string) bool {
+	lines, err := c.parse(input)
+	if errors.Is(err, ErrCliInputTooLarge) {
+		return true
+	}
+	for _, line := range lines {
+		line = c.stripAssignments(line)
+		cmd, _ := c.lookupCommand(line.Values())
+		for i := 0; cmd != nil && i <= len(c.commands); i++ {
+			if cmd.NoHistory {
+				return true
+			}
+			if cmd.Redirect == "" {
+				break
+			}
+			cmd, _ = c.getCommand(cmd.Redirect)
+		}
+	}
+	return false
+}
+
+// appendHistory adds the input item to the history, unless the settings leave
+// it out, and reports whether it was added.
+func (c *GomCLI) appendHistory(item string) bool {
+	if c.histIgnoreSpace && strings.HasPrefix(item, " ") {
+		return false
+	}
+	if c.histIgnoreDups && len(c.history) > 0 && c.history[len(c.history)-1].Line == item {
+		return false
+	}
+
+	c.flushHistory()
+	c.appendEntry(HistoryEntry{Line: item, Time: time.Now()})
+	c.histPending = true
+	return true
+}
+
+// setHistoryStatus sets the status of the latest entry of the history, once its
+// input has been run, and appends it to the HistoryStore.
+func (c *GomCLI) setHistoryStatus(failed bool) {
+	if !c.histPending || len(c.history) == 0 {
+		return
+	}
+	entry := &c.history[len(c.history)-1]
+	entry.Status = HistorySucceeded
+	if failed {
+		entry.Status = HistoryFailed
+	}
+	c.flushHistory()
+}
+
+// flushHistory appends the latest entry of the history to the HistoryStore if
+// it is still pending, i.e. its input has not finished running.
+func (c *GomCLI) flushHistory() error {
+	if !c.histPending {
+		return nil
+	}
+	c.histPending = false
+	if c.histStore != nil && len(c.history) > 0 {
+		return c.historyError(c.histStore.Append(c.history[len(c.history)-1]))
+	}
+	return nil
+}
+
+// closeHistory flushes the history, and closes the HistoryStore if it is an
+// io.Closer.
+func (c *GomCLI) closeHistory() error {
+	err := c.flushHistory()
+	if closer, ok := c.histStore.(io.Closer); ok {
+		if closeErr := c.historyError(closer.Close()); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// trimHistory drops the oldest entries exceeding the history limit, and reports
+// whether any was dropped.
+func (c *GomCLI) trimHistory() bool {
+	if c.historyLimit <= 0 || len(c.history) <= c.historyLimit {
+		return false
+	}
+	c.history = limitHistory(c.history, c.historyLimit)
+	return true
+}
+
+// limitHistory returns the latest limit entries of history, or all of them if
+// limit is 0 or less.
+func limitHistory(history []HistoryEntry, limit int) []HistoryEntry {
+	if limit <= 0 || len(history) <= limit {
+		return history
+	}
+	return append([]HistoryEntry(nil), history[len(history)-limit:]...)
+}
+
+// syncHistory replaces the history of the lineReader with the current one.
+func (c *GomCLI) syncHistory() {
+	c.lr.ClearHistory()
+	for _, entry := range c.history {
+		c.lr.AppendHistory(entry.Line)
+	}
+}
+
+// fileHistoryStore is the HistoryStore set by SetHistoryFile, keeping one
+// entry per line in the plain or extended format, the newlines within entries
+// being escaped with a backslash as in zsh. Entries are appended to the file,
+// which is only rewritten once it exceeds the history limit by half of it,
+// when the history is saved, and on Close, which truncates it to the limit.
+// The file is locked while it is written, so that
+// concurrent instances do not discard each other's entries.
+type fileHistoryStore struct {
+	cli  *GomCLI
+	path string
+
+	// entries is the number of entries in the file as of the last time it was
+	// read or written by this store, not counting those appended since by
+	// other instances.
+	entries int
+}
+
+func (s *fileHistoryStore) Load() ([]HistoryEntry, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	history, err := readHistory(f)
+	s.entries = len(history)
+	return history, err
+}
+
+func (s *fileHistoryStore) Append(entry HistoryEntry) error {
+	// Letting the file grow past the limit by half of it before truncating
+	// keeps appending cheap once the limit is reached.
+	if limit := s.cli.historyLimit; limit > 0 && s.entries >= limit+limit/2 {
+		return s.update(func(history []HistoryEntry) []HistoryEntry {
+			return append(history, entry)
+		})
+	}
+
+	f, err := s.open(os.O_WRONLY | os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	if _, err := f.WriteString(s.format(entry)); err != nil {
+		return err
+	}
+	s.entries++
+	return nil
+}
+
+func (s *fileHistoryStore) Save(history []HistoryEntry) error {
+	return s.update(func([]HistoryEntry) []HistoryEntry {
+		return history
+	})
+}
+
+// Close rewrites the file applying the history settings of the CLI, e.g. to
+// erase the duplicates appended during the session.
+func (s *fileHistoryStore) Close() error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+	return s.update(func(history []HistoryEntry) []HistoryEntry {
+		return history
+	})
+}
+
+// open opens the file with flag, creating it along with its directory if
+// needed, and locks it.
+func (s *fileHistoryStore) open(flag int) (*os.File, error) {
+	dirName := filepath.Dir(s.path)
+	if _, err := os.Stat(dirName); err != nil {
+		err := os.MkdirAll(dirName, os.ModePerm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(s.path, flag|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// update replaces the entries of the file by the result of fn, applying the
+// history settings of the CLI, while holding a lock on the file.
+func (s *fileHistoryStore) update(fn func([]HistoryEntry) []HistoryEntry) error {
+	f, err := s.open(os.O_RDWR)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer unlockFile(f)
+
+	history, err := readHistory(f)
+	if err != nil {
+		return err
+	}
+	history = fn(history)
+	if s.cli.histEraseDups {
+		history = eraseDups(history)
+	}
+	history = limitHistory(history, s.cli.historyLimit)
+	s.entries = len(history)
+
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, entry := range history {
+		w.WriteString(s.format(entry))
+	}
+	return w.Flush()
+}
+
+// format returns entry as written to the file, newline included.
+func (s *fileHistoryStore) format(entry HistoryEntry) string {
+	entry.Line = strings.ReplaceAll(entry.Line, "\n", "\\\n")
+	if s.cli.histExtended {
+		return formatHistoryEntry(entry) + "\n"
+	}
+	return entry.Line + "\n"
+}
+
+func readHistory(r io.Reader) ([]HistoryEntry, error) {
+	var history []HistoryEntry
+	var entry string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if continuesLine(line) {
+			entry += line[:len(line)-1] + "\n"
+			continue
+		}
+		history = append(history, parseHistoryEntry(entry+line))
+		entry = ""
+	}
+	if entry != "" {
+		history = append(history, parseHistoryEntry(entry[:len(entry)-1]))
+	}
+	return history, scanner.Err()
+}
+
+// parseHistoryEntry parses a line of a history file, in either format.
+func parseHistoryEntry(line string) HistoryEntry {
+	m := extendedHistoryEntry.FindStringSubmatch(line)
+	if m == nil {
+		return HistoryEntry{Line: line}
+	}
+
+	entry := HistoryEntry{Line: line[len(m[0]):]}
+	if sec, err := strconv.ParseInt(m[1], 10, 64); err == nil && sec != 0 {
+		entry.Time = time.Unix(sec, 0)
+	}
+	switch m[2] {
+	case "0":
+		entry.Status = HistorySucceeded
+	case "1":
+		entry.Status = HistoryFailed
+	}
+	return entry
+}
+
+func formatHistoryEntry(entry HistoryEntry) string {
+	var sec int64
+	if !entry.Time.IsZero() {
+		sec = entry.Time.Unix()
+	}
+	status := "-"
+	switch entry.Status {
+	case HistorySucceeded:
+		status = "0"
+	case HistoryFailed:
+		status = "1"
+	}
+	return fmt.Sprintf(": %d:%s;%s", sec, status, entry.Line)
+}
+
+// eraseDups returns history keeping only the latest occurrence of each line.
+func eraseDups(history []HistoryEntry) []HistoryEntry {
+	seen := make(map[string]bool, len(history))
+	res := make([]HistoryEntry, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		if !seen[history[i].Line] {
+			seen[history[i].Line] = true
+			res = append(res, history[i])
+		}
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
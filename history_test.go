@@ -0,0 +1,63 @@
+package gomcli
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHistoryFileMultilineEntries(t *testing.T) {
+	for _, extended := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "history")
+		lines := []string{"echo 'a\nb'", "echo c", "echo 'd\n\ne'"}
+
+		c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+		c.SetHistoryExtended(extended)
+		c.SetHistoryFile(path)
+		for _, line := range lines {
+			c.AppendHistory(line)
+		}
+		c.Close()
+
+		c = NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+		c.SetHistoryExtended(extended)
+		c.SetHistoryFile(path)
+		if got := c.History(); !reflect.DeepEqual(got, lines) {
+			t.Errorf("extended=%v: History() = %q, want %q", extended, got, lines)
+		}
+		c.Close()
+	}
+}
+
+func TestHistoryFileAppendsAndTruncates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.SetHistoryLimit(4)
+	c.SetHistoryFile(path)
+	store := c.histStore.(*fileHistoryStore)
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		line := "cmd " + strconv.Itoa(i)
+		c.AppendHistory(line)
+		want = append(want, line)
+		if store.entries > 6 {
+			t.Fatalf("file holds %d entries, want at most 6", store.entries)
+		}
+	}
+	c.Close()
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range loaded {
+		got = append(got, entry.Line)
+	}
+	if !reflect.DeepEqual(got, want[6:]) {
+		t.Errorf("file = %q, want %q", got, want[6:])
+	}
+}
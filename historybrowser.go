@@ -0,0 +1,96 @@
+package gomcli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EnableHistoryBrowser registers the built-in "history" Command, which shows
+// the entries of the history, numbered, in the viewer of View, only those
+// containing the given text if any, e.g. "history deploy". Once the viewer is
+// quit, entries can be selected by number or range, e.g. "3 5-7", to run them
+// again in order, or the selection can be prefixed with "c" to copy them to
+// the clipboard instead.
+func (c *GomCLI) EnableHistoryBrowser() {
+	c.AddCommand(Command{
+		Name:    "history",
+		Help:    "Browse the history, and run or copy entries",
+		Usage:   "history [text]",
+		builtin: historyBuiltin,
+	})
+}
+
+func historyBuiltin(s *Session, args []string) error {
+	c := s.cli
+	filter := strings.Join(args, " ")
+	history := c.History()
+	if c.histPending {
+		// The latest entry is the input running this Command.
+		history = history[:len(history)-1]
+	}
+
+	var entries []string
+	var b strings.Builder
+	for _, line := range history {
+		if !strings.Contains(line, filter) {
+			continue
+		}
+		entries = append(entries, line)
+		fmt.Fprintf(&b, "%5d  %v\n", len(entries), strings.ReplaceAll(line, "\n", "\n       "))
+	}
+	if len(entries) == 0 {
+		s.Println("No history entries")
+		return nil
+	}
+
+	if err := c.View(strings.NewReader(b.String()), "history"); err != nil {
+		return err
+	}
+	answer, err := c.lr.Prompt("Run entries (e.g. 3 5-7), or copy them (c 3): ")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(answer)
+	copyEntries := len(fields) > 0 && fields[0] == "c"
+	if copyEntries {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	selected, err := selectEntries(entries, fields)
+	if err != nil {
+		s.Println(err)
+		return nil
+	}
+	if copyEntries {
+		return CopyToClipboard(strings.Join(selected, "\n"))
+	}
+	for _, line := range selected {
+		if err := c.processInput(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectEntries returns the entries at the positions given by fields, from 1,
+// as numbers or ranges such as "5-7".
+func selectEntries(entries []string, fields []string) ([]string, error) {
+	var selected []string
+	for _, f := range fields {
+		from, to, isRange := strings.Cut(f, "-")
+		if !isRange {
+			to = from
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || start < 1 || end > len(entries) || start > end {
+			return nil, fmt.Errorf("Invalid selection %q", f)
+		}
+		selected = append(selected, entries[start-1:end]...)
+	}
+	return selected, nil
+}
@@ -0,0 +1,36 @@
+package gomcli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistoryBrowserRunsSelection(t *testing.T) {
+	input := "echo a\necho b\necho c\nhistory echo\n\n3 1\n"
+	c := NewWithIO(strings.NewReader(input), &bytes.Buffer{})
+	c.EnableHistoryBrowser()
+	var ran []string
+	c.AddCommand(Command{Name: "echo", Function: func(word string) {
+		ran = append(ran, word)
+	}})
+
+	c.Start()
+	if want := []string{"a", "b", "c", "c", "a"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
+
+func TestSelectEntries(t *testing.T) {
+	entries := []string{"a", "b", "c", "d"}
+	got, err := selectEntries(entries, []string{"2-3", "1"})
+	if err != nil || !reflect.DeepEqual(got, []string{"b", "c", "a"}) {
+		t.Errorf("selectEntries = %q, %v", got, err)
+	}
+	for _, f := range []string{"0", "5", "3-2", "x"} {
+		if _, err := selectEntries(entries, []string{f}); err == nil {
+			t.Errorf("selectEntries(%q) succeeded", f)
+		}
+	}
+}
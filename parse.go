@@ -0,0 +1,276 @@
+package gomcli
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Separator indicates what terminates a ParsedLine within the input.
+type Separator int
+
+const (
+	// SeparatorNone marks the last command of the input.
+	SeparatorNone Separator = iota
+	// SeparatorSemicolon marks a command followed by an unquoted ";".
+	SeparatorSemicolon
+	// SeparatorNewline marks a command followed by an unquoted newline.
+	SeparatorNewline
+)
+
+// Token is a word of the input, after quotes and escapes are processed. Start
+// and End are the byte offsets of the word as typed within the input, so that
+// Raw equals input[Start:End].
+type Token struct {
+	Value  string
+	Raw    string
+	Start  int
+	End    int
+	Quoted bool
+}
+
+// ParsedLine is a single command of the input, as split by Parse. Raw is the
+// text of the command as typed, starting at byte Offset within the input, and
+// Separator indicates how the command was terminated. Pipes holds the external
+// programs the output of the command is piped to, in order, if enabled with
+// EnableShellPipes.
+type ParsedLine struct {
+	Raw       string
+	Offset    int
+	Tokens    []Token
+	Separator Separator
+	Pipes     []ParsedLine
+}
+
+// Values returns the values of the Tokens of the line, i.e. the command and
+// its arguments.
+func (p ParsedLine) Values() []string {
+	values := make([]string, len(p.Tokens))
+	for i, tok := range p.Tokens {
+		values[i] = tok.Value
+	}
+	return values
+}
+
+// Parse splits input into the commands it contains, following the quoting
+// rules of POSIX shells: single quotes preserve their content literally,
+// double quotes allow escaping '"' and '\' with a backslash, and a backslash
+// outside quotes escapes any character but a newline, which continues the
+// line. Commands are separated by unquoted semicolons and newlines; empty
+// commands are skipped. An unquoted "#" starting the first word of a command
+// starts a comment, up to the end of the line. On error, a *ParseError is
+// returned along with the commands parsed so far, the last one being
+// incomplete.
+func Parse(input string) ([]ParsedLine, error) {
+	p := parser{input: input}
+	return p.parse()
+}
+
+// parse splits input with Parse, also allowing comments after the first word
+// of a command if enabled with SetTrailingComments, splitting pipes if enabled
+// with EnableShellPipes, and enforcing the limits set with SetMaxInputLength and
+// SetMaxTokens.
+func (c *GomCLI) parse(input string) ([]ParsedLine, error) {
+	if c.maxInputLength > 0 && len(input) > c.maxInputLength {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d",
+			ErrCliInputTooLarge, len(input), c.maxInputLength)
+	}
+
+	p := parser{
+		input:            input,
+		trailingComments: c.trailingComments,
+		pipes:            c.shellPipes,
+		maxTokens:        c.maxTokens,
+	}
+	return p.parse()
+}
+
+type parser struct {
+	input            string
+	trailingComments bool
+	pipes            bool
+	maxTokens        int
+	tokens           int
+	lines            []ParsedLine
+	line             ParsedLine
+	pipeline         []ParsedLine
+	tok              *Token
+	value            strings.Builder
+}
+
+func (p *parser) parse() ([]ParsedLine, error) {
+	var quote byte
+	quoteStart := 0
+
+	for i := 0; i < len(p.input); i++ {
+		if p.tooManyTokens() {
+			return p.lines, p.tokensError()
+		}
+
+		ch := p.input[i]
+		switch {
+		case quote == '\'':
+			if ch == '\'' {
+				quote = 0
+			} else {
+				p.value.WriteByte(ch)
+			}
+		case quote == '"':
+			if ch == '"' {
+				quote = 0
+			} else if ch == '\\' && i+1 < len(p.input) &&
+				(p.input[i+1] == '"' || p.input[i+1] == '\\') {
+				i++
+				p.value.WriteByte(p.input[i])
+			} else {
+				p.value.WriteByte(ch)
+			}
+		case ch == '\n':
+			if err := p.endLine(i, SeparatorNewline); err != nil {
+				return p.lines, err
+			}
+		case isSpace(ch):
+			p.endToken(i)
+		case ch == ';':
+			if i+1 < len(p.input) && p.input[i+1] == ';' {
+				p.endLine(i, SeparatorNone)
+				return p.lines, newParseError(p.input, i, `stray ";;"`)
+			}
+			if err := p.endLine(i, SeparatorSemicolon); err != nil {
+				return p.lines, err
+			}
+		case ch == '|' && p.pipes:
+			p.endToken(i)
+			if len(p.line.Tokens) == 0 {
+				p.endLine(i, SeparatorNone)
+				return p.lines, newParseError(p.input, i, `missing command before "|"`)
+			}
+			p.pipeline = append(p.pipeline, p.takeLine(SeparatorNone))
+		case ch == '\\':
+			if i == len(p.input)-1 {
+				p.endLine(i, SeparatorNone)
+				return p.lines, newParseError(p.input, i, "trailing backslash")
+			}
+			if p.input[i+1] == '\n' {
+				i++
+				continue
+			}
+			p.startToken(i)
+			_, size := utf8.DecodeRuneInString(p.input[i+1:])
+			p.value.WriteString(p.input[i+1 : i+1+size])
+			i += size
+		case ch == '#' && p.tok == nil && (p.trailingComments || len(p.line.Tokens) == 0):
+			if end := strings.IndexByte(p.input[i:], '\n'); end >= 0 {
+				i += end - 1
+			} else {
+				i = len(p.input)
+			}
+		case ch == '\'' || ch == '"':
+			p.startToken(i)
+			p.tok.Quoted = true
+			quote, quoteStart = ch, i
+		default:
+			p.startToken(i)
+			p.value.WriteByte(ch)
+		}
+	}
+
+	if err := p.endLine(len(p.input), SeparatorNone); err != nil {
+		return p.lines, err
+	}
+	if p.tooManyTokens() {
+		return p.lines, p.tokensError()
+	}
+	if quote != 0 {
+		return p.lines, newParseError(p.input, quoteStart, "unterminated quote")
+	}
+	return p.lines, nil
+}
+
+func (p *parser) tooManyTokens() bool {
+	return p.maxTokens > 0 && p.tokens > p.maxTokens
+}
+
+func (p *parser) tokensError() error {
+	return fmt.Errorf("%w: more than %d words", ErrCliInputTooLarge, p.maxTokens)
+}
+
+func (p *parser) startToken(i int) {
+	if p.tok == nil {
+		p.tok = &Token{Start: i}
+	}
+}
+
+func (p *parser) endToken(i int) {
+	if p.tok == nil {
+		return
+	}
+	p.tok.End = i
+	p.tok.Raw = p.input[p.tok.Start:i]
+	p.tok.Value = p.value.String()
+	p.line.Tokens = append(p.line.Tokens, *p.tok)
+	p.tokens++
+	p.tok = nil
+	p.value.Reset()
+}
+
+// endLine ends the current command at byte i, along with the programs it is
+// piped to, if any.
+func (p *parser) endLine(i int, sep Separator) error {
+	p.endToken(i)
+	if len(p.pipeline) == 0 {
+		if len(p.line.Tokens) > 0 {
+			p.lines = append(p.lines, p.takeLine(sep))
+		}
+		return nil
+	}
+
+	line := p.pipeline[0]
+	line.Separator = sep
+	line.Pipes = p.pipeline[1:]
+	p.pipeline = nil
+	if len(p.line.Tokens) == 0 {
+		p.lines = append(p.lines, line)
+		return newParseError(p.input, i, `missing command after "|"`)
+	}
+	line.Pipes = append(line.Pipes, p.takeLine(SeparatorNone))
+	p.lines = append(p.lines, line)
+	return nil
+}
+
+// takeLine returns the current command, which must have some Tokens, and
+// starts a new one.
+func (p *parser) takeLine(sep Separator) ParsedLine {
+	line := p.line
+	n := len(line.Tokens)
+	line.Offset = line.Tokens[0].Start
+	line.Raw = p.input[line.Offset:line.Tokens[n-1].End]
+	line.Separator = sep
+	p.line = ParsedLine{}
+	return line
+}
+
+func isSpace(ch byte) bool {
+	switch ch {
+	case ' ', '\t', '\n', '\v', '\f', '\r':
+		return true
+	}
+	return false
+}
+
+// quoteWords joins words with spaces, quoting them so that Parse would return
+// the same words.
+func quoteWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quoteWord(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quoteWord(w string) string {
+	if w != "" && !strings.ContainsAny(w, " \t\n\v\f\r'\"\\;|") {
+		return w
+	}
+	return "'" + strings.ReplaceAll(w, "'", `'\''`) + "'"
+}
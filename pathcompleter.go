@@ -0,0 +1,110 @@
+package gomcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilePathCompleter returns a Completer of file paths, relative to baseDir, or
+// to the working directory of the process if empty, unless they are absolute
+// or start with "~/". Directories are completed with a trailing "/", and only
+// the files with any of the given extensions, e.g. ".csv", are proposed if
+// some are given. Hidden entries are only proposed when the text being
+// completed starts with a ".". The candidates are escaped with backslashes, so
+// that they are parsed back as a single word.
+func FilePathCompleter(baseDir string, extensions ...string) Completer {
+	return pathCompleter(func(dir string) string {
+		lookup := dir
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			lookup = filepath.Join(home, dir[2:])
+		}
+		if !filepath.IsAbs(lookup) {
+			lookup = filepath.Join(baseDir, lookup)
+		}
+		if lookup == "" {
+			lookup = "."
+		}
+		return lookup
+	}, extensions)
+}
+
+// SessionPathCompleter is like FilePathCompleter, but relative paths and
+// baseDir are relative to the working directory of the Session, so that the
+// completion follows "cd".
+func (c *GomCLI) SessionPathCompleter(baseDir string, extensions ...string) Completer {
+	return pathCompleter(func(dir string) string {
+		if baseDir != "" && !filepath.IsAbs(dir) && !strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(baseDir, dir)
+		}
+		return c.session.Abs(dir)
+	}, extensions)
+}
+
+// pathCompleter returns a Completer of the entries of the directories named by
+// the text being completed, as resolved by lookup, or none if it returns "".
+func pathCompleter(lookup func(dir string) string, extensions []string) Completer {
+	return func(prefix string) []string {
+		dir, file := filepath.Split(prefix)
+		path := lookup(dir)
+		if path == "" {
+			return nil
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil
+		}
+
+		var res []string
+		for _, e := range entries {
+			name := e.Name()
+			if !strings.HasPrefix(name, file) || (name[0] == '.' && !strings.HasPrefix(file, ".")) {
+				continue
+			}
+			isDir := e.IsDir()
+			if e.Type()&os.ModeSymlink != 0 {
+				if fi, err := os.Stat(filepath.Join(path, name)); err == nil {
+					isDir = fi.IsDir()
+				}
+			}
+			if isDir {
+				res = append(res, escapeWord(dir+name+"/"))
+			} else if hasExtension(name, extensions) {
+				res = append(res, escapeWord(dir+name))
+			}
+		}
+		return res
+	}
+}
+
+// hasExtension reports whether name ends with any of extensions, or whether
+// there are none.
+func hasExtension(name string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeWord escapes with backslashes the characters of w that Parse would not
+// take literally.
+func escapeWord(w string) string {
+	var b strings.Builder
+	for _, r := range w {
+		if strings.ContainsRune(" \t\n\v\f\r'\"\\;|$#", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
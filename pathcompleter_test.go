@@ -0,0 +1,51 @@
+package gomcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionPathCompleter(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"data.csv", "notes.txt", "my file.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	if err := c.Session().Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	complete := c.SessionPathCompleter("", ".csv")
+
+	if got, want := complete(""), []string{"data.csv", `my\ file.csv`, "sub/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("complete(\"\") = %q, want %q", got, want)
+	}
+	if got, want := complete("su"), []string{"sub/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("complete(\"su\") = %q, want %q", got, want)
+	}
+}
+
+func TestFilePathCompleter(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.csv", "b.txt", ".hidden.csv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	complete := FilePathCompleter(dir, ".csv")
+	if got, want := complete(""), []string{"a.csv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("complete(\"\") = %q, want %q", got, want)
+	}
+	if got, want := complete("."), []string{".hidden.csv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("complete(\".\") = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package gomcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// EnableShellPipes sets whether an unquoted "|" pipes the output of a Command
+// to an external program, as in "showlog | grep error". The words following it
+// make up the program and its arguments, which can be piped in turn to further
+// programs. The programs run with the environment of the process, in the
+// working directory of the Session. The default is false, so that arguments
+// can contain "|" and no program can be run from the CLI.
+func (c *GomCLI) EnableShellPipes(value bool) {
+	c.shellPipes = value
+}
+
+// processPipeline processes line with its output piped to the programs of
+// line.Pipes, waiting for them to exit. Errors starting the programs or
+// reported by them fail the input like Command errors.
+func (c *GomCLI) processPipeline(line ParsedLine) error {
+	pipes := line.Pipes
+	line.Pipes = nil
+
+	var cmdErr error
+	err := c.checkPipes()
+	if err == nil {
+		err = c.runPipeline(pipes, func() error {
+			cmdErr = c.dispatch(line)
+			return cmdErr
+		})
+	}
+	if cmdErr != nil || err == nil {
+		return cmdErr
+	}
+	return c.failLine(err)
+}
+
+// failLine fails the input with err, which is returned if SetExitOnCmdError is
+// enabled, or else printed.
+func (c *GomCLI) failLine(err error) error {
+	c.lastFailed = true
+	c.session.setStatus(StatusFailed)
+	if c.exitOnCmdError {
+		return err
+	}
+	c.errorf("%v\n", err)
+	return nil
+}
+
+// checkPipes returns an error wrapping ErrCliCommandNotAllowed if any of the
+// Restrictions in effect does not allow pipes.
+func (c *GomCLI) checkPipes() error {
+	for _, r := range c.restrictions {
+		if r.NoPipes {
+			return fmt.Errorf("%w: %q", ErrCliCommandNotAllowed, "|")
+		}
+	}
+	return nil
+}
+
+// lockedWriter returns w, serializing its writes with the Session output unless
+// it is a file, which the programs of a pipeline can write to directly.
+func (s *Session) lockedWriter(w io.Writer) io.Writer {
+	if f, ok := w.(*os.File); ok {
+		return f
+	}
+	return syncWriter{&s.outMu, w}
+}
+
+// runPipeline starts the programs of pipes and calls fn with the output of the
+// Session piped to the first one, returning the first error from fn or any of
+// the programs.
+func (c *GomCLI) runPipeline(pipes []ParsedLine, fn func() error) error {
+	s := c.session
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	s.outMu.Lock()
+	out, errOut := s.lockedWriter(s.out), s.lockedWriter(c.errWriter())
+	s.outMu.Unlock()
+
+	cmds := make([]*exec.Cmd, len(pipes))
+	for i, p := range pipes {
+		args := p.Values()
+		cmds[i] = exec.CommandContext(s.Context(), args[0], args[1:]...)
+		cmds[i].Dir = s.Dir()
+		cmds[i].Stderr = errOut
+		if i == 0 {
+			cmds[i].Stdin = r
+		} else if cmds[i].Stdin, err = cmds[i-1].StdoutPipe(); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		cmds[len(cmds)-1].Stdout = out
+		for i, cmd := range cmds {
+			if err = cmd.Start(); err != nil {
+				cmds = cmds[:i]
+				break
+			}
+		}
+	}
+	r.Close()
+	if err != nil {
+		w.Close()
+		for _, cmd := range cmds {
+			cmd.Wait()
+		}
+		return err
+	}
+
+	restore := s.redirect(w)
+	err = fn()
+	restore()
+	w.Close()
+
+	for _, cmd := range cmds {
+		if werr := cmd.Wait(); werr != nil && err == nil {
+			err = fmt.Errorf("%v: %w", cmd.Args[0], werr)
+		}
+	}
+	return err
+}
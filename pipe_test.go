@@ -0,0 +1,48 @@
+package gomcli
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestPipelineStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var out, errOut bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.SetErrOutput(&errOut)
+	c.EnableShellPipes(true)
+	c.AddCommand(Command{Name: "show", Function: func(s *Session) {
+		s.Println("hello")
+	}})
+
+	if err := c.processInput(`show | sh -c "cat; echo oops >&2"`); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "hello\n" {
+		t.Errorf("output = %q, want %q", got, "hello\n")
+	}
+	if got := errOut.String(); got != "oops\n" {
+		t.Errorf("error output = %q, want %q", got, "oops\n")
+	}
+}
+
+func TestPipelineStderrCaptured(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.EnableShellPipes(true)
+	c.AddCommand(Command{Name: "show", Function: func() {}})
+
+	out, err := c.Session().Capture(func() error {
+		return c.processInput(`show | sh -c "echo oops >&2"`)
+	})
+	if err != nil || out != "oops\n" {
+		t.Errorf("Capture = %q, %v, want %q", out, err, "oops\n")
+	}
+}
@@ -2,28 +2,212 @@ package gomcli
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 )
 
 var lock sync.Mutex
 
+// output is the destination of the package-level printer functions, shared by
+// all the GomCLI instances of the process: it follows the output of the one set
+// up last, unless a redirection is active. Access is guarded by lock.
+var output io.Writer = os.Stdout
+
+// redirection is an output temporarily set by a Session, e.g. with Capture.
+type redirection struct {
+	w io.Writer
+}
+
+// redirections are the active redirections of all the Sessions, innermost
+// last. Each is removed by its owner only, so that the captures of different
+// instances overlapping in time do not leave the printer functions writing to
+// the writer of a finished one. Access is guarded by lock.
+var redirections []*redirection
+
+// currentOutput returns the writer of the printer functions. lock must be held.
+func currentOutput() io.Writer {
+	if n := len(redirections); n > 0 {
+		return redirections[n-1].w
+	}
+	return output
+}
+
+// removeRedirection removes r from redirections. lock must be held.
+func removeRedirection(r *redirection) {
+	for i := len(redirections) - 1; i >= 0; i-- {
+		if redirections[i] == r {
+			redirections = append(redirections[:i], redirections[i+1:]...)
+			return
+		}
+	}
+}
+
+// syncWriter serializes the writes to w with mu, so that the package-level
+// printer functions and the Session of a GomCLI can share a writer.
+type syncWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// errorf prints a diagnostic message to the error output of the CLI.
+func (c *GomCLI) errorf(format string, a ...interface{}) {
+	c.session.outMu.Lock()
+	defer c.session.outMu.Unlock()
+	fmt.Fprintf(c.errWriter(), format, a...)
+}
+
+// errWriter returns the error output of the CLI, which is the Session output
+// unless set with SetErrOutput. The Session output lock must be held.
+func (c *GomCLI) errWriter() io.Writer {
+	if c.errOut != nil {
+		return c.errOut
+	}
+	return c.session.out
+}
+
 // Print is a wrapper over fmt.Print for thread-safe usage from gomcli.
 func Print(a ...interface{}) (n int, err error) {
 	lock.Lock()
 	defer lock.Unlock()
-	return fmt.Print(a...)
+	return fmt.Fprint(currentOutput(), a...)
 }
 
 // Printf is a wrapper over fmt.Printf for thread-safe usage from gomcli
 func Printf(format string, a ...interface{}) (n int, err error) {
 	lock.Lock()
 	defer lock.Unlock()
-	return fmt.Printf(format, a...)
+	return fmt.Fprintf(currentOutput(), format, a...)
 }
 
 // Println is a wrapper over fmt.Println for thread-safe usage from gomcli
 func Println(a ...interface{}) (n int, err error) {
 	lock.Lock()
 	defer lock.Unlock()
-	return fmt.Println(a...)
+	return fmt.Fprintln(currentOutput(), a...)
+}
+
+// SetTitle sets the terminal window title, e.g. to reflect the current context.
+// It does nothing if the terminal is not known to support it.
+func SetTitle(title string) {
+	if !oscSupported() {
+		return
+	}
+	Print("\x1b]0;" + title + "\x07")
+}
+
+// Hyperlink returns text wrapped in an OSC 8 hyperlink to url, to be printed
+// with any of the printer functions. If the terminal is not known to support
+// it, plain text including the url is returned instead.
+func Hyperlink(url, text string) string {
+	if !oscSupported() {
+		if text == "" || text == url {
+			return url
+		}
+		return text + " (" + url + ")"
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// outputTerminal returns the output of the printer functions if it is an
+// interactive terminal.
+func outputTerminal() (*os.File, bool) {
+	lock.Lock()
+	w := currentOutput()
+	lock.Unlock()
+	if sw, ok := w.(syncWriter); ok {
+		w = sw.w
+	}
+	f, ok := w.(*os.File)
+	return f, ok && isTerminalFile(f)
+}
+
+// oscSupported reports whether the output of the printer functions is a
+// terminal known to support OSC sequences.
+func oscSupported() bool {
+	if _, ok := outputTerminal(); !ok {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return os.Getenv("WT_SESSION") != ""
+	}
+	term := os.Getenv("TERM")
+	return term != "" && term != "dumb"
+}
+
+const defaultTerminalWidth = 80
+
+// PrintHexDump prints data in the style of hexdump -C, with offsets and an
+// ASCII gutter. The number of bytes per line is adapted to the terminal width.
+func PrintHexDump(data []byte) (n int, err error) {
+	return Print(hexDump(data, terminalWidth()))
+}
+
+func hexDump(data []byte, width int) string {
+	perLine := 4
+	for _, n := range []int{32, 16, 8} {
+		if hexDumpLineWidth(n) <= width {
+			perLine = n
+			break
+		}
+	}
+
+	var b strings.Builder
+	for offset := 0; offset < len(data); offset += perLine {
+		end := offset + perLine
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[offset:end]
+
+		fmt.Fprintf(&b, "%08x  ", offset)
+		for i := 0; i < perLine; i++ {
+			if i > 0 && i%8 == 0 {
+				b.WriteByte(' ')
+			}
+			if i < len(chunk) {
+				fmt.Fprintf(&b, "%02x ", chunk[i])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+
+		b.WriteString(" |")
+		for _, c := range chunk {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	fmt.Fprintf(&b, "%08x\n", len(data))
+	return b.String()
+}
+
+func hexDumpLineWidth(perLine int) int {
+	return 10 + perLine*3 + (perLine-1)/8 + 1 + perLine + 2
+}
+
+// terminalWidth returns the width of the output of the printer functions if it
+// is a terminal, or else as set in $COLUMNS, or else defaultTerminalWidth.
+func terminalWidth() int {
+	if f, ok := outputTerminal(); ok {
+		if n := terminalColumns(f); n > 0 {
+			return n
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultTerminalWidth
 }
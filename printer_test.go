@@ -0,0 +1,19 @@
+package gomcli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTerminalWidthFollowsOutput(t *testing.T) {
+	t.Setenv("COLUMNS", "123")
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	defer c.Close()
+
+	if got := terminalWidth(); got != 123 {
+		t.Errorf("terminalWidth() = %d, want 123 from $COLUMNS", got)
+	}
+	if oscSupported() {
+		t.Error("oscSupported() for a buffer output")
+	}
+}
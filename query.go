@@ -0,0 +1,193 @@
+package gomcli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrQueryNoResult is returned by Query when no Command has produced a result
+// yet.
+var ErrQueryNoResult = errors.New("No result to query")
+
+// ErrQueryInvalidPath is returned by Query when the path cannot be parsed or
+// does not match the structure of the result.
+var ErrQueryInvalidPath = errors.New("Invalid query path")
+
+// LastResult returns the first non-error value returned by the Function of the
+// last Command that returned any, or nil if none did.
+func (s *Session) LastResult() interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastResult
+}
+
+func (s *Session) setLastResult(result interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastResult = result
+}
+
+// Query extracts a value from the last result using a path such as
+// .items[0].name, where fields match struct field names, their json tag or map
+// keys, and brackets hold slice indexes or quoted map keys. The path "." returns
+// the whole result.
+func (s *Session) Query(path string) (interface{}, error) {
+	result := s.LastResult()
+	if result == nil {
+		return nil, ErrQueryNoResult
+	}
+	return evalQuery(result, path)
+}
+
+// EnableQuery registers a built-in "query" Command, which prints the value
+// extracted from the last result by Session.Query, and assigns it to a session
+// variable if a name is given after the path.
+func (c *GomCLI) EnableQuery() {
+	c.AddCommand(Command{
+		Name:    "query",
+		Help:    "Extract a value from the last result",
+		Usage:   "query <path> [variable]",
+		builtin: queryBuiltin,
+	})
+}
+
+func queryBuiltin(s *Session, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		s.Println("Usage: query <path> [variable]")
+		return nil
+	}
+
+	value, err := s.Query(args[0])
+	if err != nil {
+		s.Println(err)
+		return nil
+	}
+
+	text, err := formatQueryValue(value)
+	if err != nil {
+		s.Println(err)
+		return nil
+	}
+
+	if len(args) == 2 {
+		s.SetVar(args[1], text)
+		return nil
+	}
+	s.Println(text)
+	return nil
+}
+
+func formatQueryValue(value interface{}) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		out, err := json.MarshalIndent(value, "", "  ")
+		return string(out), err
+	case reflect.Invalid:
+		return "null", nil
+	default:
+		return fmt.Sprint(v.Interface()), nil
+	}
+}
+
+func evalQuery(root interface{}, path string) (interface{}, error) {
+	path = strings.TrimSpace(path)
+	if path == "" || (path[0] != '.' && path[0] != '[') {
+		return nil, fmt.Errorf("%w: %q", ErrQueryInvalidPath, path)
+	}
+
+	v := reflect.ValueOf(root)
+	for path != "" {
+		var step string
+		var index bool
+
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			step, path = path[:end], path[end:]
+			if step == "" {
+				continue
+			}
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated \"[\"", ErrQueryInvalidPath)
+			}
+			step, path = path[1:end], path[end+1:]
+			if unquoted, err := strconv.Unquote(step); err == nil {
+				step = unquoted
+			} else {
+				index = true
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q", ErrQueryInvalidPath, path[0])
+		}
+
+		var err error
+		if v, err = queryStep(v, step, index); err != nil {
+			return nil, err
+		}
+	}
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+	return v.Interface(), nil
+}
+
+func queryStep(v reflect.Value, step string, index bool) (reflect.Value, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, fmt.Errorf("%w: %q of nil value", ErrQueryInvalidPath, step)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(step)
+		if err != nil || !index {
+			return v, fmt.Errorf("%w: %q is not an index", ErrQueryInvalidPath, step)
+		}
+		if i < 0 {
+			i += v.Len()
+		}
+		if i < 0 || i >= v.Len() {
+			return v, fmt.Errorf("%w: index %v out of range", ErrQueryInvalidPath, step)
+		}
+		return v.Index(i), nil
+	case reflect.Map:
+		key, err := convertStringToType(v.Type().Key(), step)
+		if err != nil {
+			return v, fmt.Errorf("%w: %q is not a valid key", ErrQueryInvalidPath, step)
+		}
+		value := v.MapIndex(key)
+		if !value.IsValid() {
+			return v, fmt.Errorf("%w: key %q not found", ErrQueryInvalidPath, step)
+		}
+		return value, nil
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			tag := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.Name == step || tag == step || strings.EqualFold(f.Name, step) {
+				return v.Field(i), nil
+			}
+		}
+		return v, fmt.Errorf("%w: field %q not found", ErrQueryInvalidPath, step)
+	default:
+		return v, fmt.Errorf("%w: cannot select %q from %v", ErrQueryInvalidPath, step, v.Kind())
+	}
+}
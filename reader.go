@@ -0,0 +1,71 @@
+package gomcli
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// lineReader is the backend used by GomCLI to read user input and keep the
+// history. Liner is used by default, unless gomcli is built with the
+// gomcli_noliner build tag, in which case a dumbReader on the standard streams
+// is used instead.
+type lineReader interface {
+	Prompt(prompt string) (string, error)
+	PasswordPrompt(prompt string) (string, error)
+	AppendHistory(item string)
+	ClearHistory()
+	SetCtrlCAborts(aborts bool)
+	Close() error
+}
+
+// dumbReader is a lineReader without line editing, completion nor history
+// navigation, reading lines from any io.Reader and writing the prompts to an
+// io.Writer.
+type dumbReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newDumbReader(in io.Reader, out io.Writer) *dumbReader {
+	return &dumbReader{in: bufio.NewReader(in), out: out}
+}
+
+func (d *dumbReader) Prompt(prompt string) (string, error) {
+	io.WriteString(d.out, prompt)
+
+	line, err := d.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (d *dumbReader) PasswordPrompt(prompt string) (string, error) {
+	return d.Prompt(prompt)
+}
+
+func (d *dumbReader) AppendHistory(item string) {}
+
+func (d *dumbReader) ClearHistory() {}
+
+func (d *dumbReader) SetCtrlCAborts(aborts bool) {}
+
+func (d *dumbReader) Close() error {
+	return nil
+}
+
+// isTerminalFile reports whether f is an interactive terminal.
+func isTerminalFile(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// newBatchReader returns a dumbReader on the standard streams for when the
+// standard input is not a terminal, setting up c to run it as a script.
+func newBatchReader(c *GomCLI) lineReader {
+	d := newDumbReader(os.Stdin, os.Stdout)
+	c.batchInput = d.in
+	return d
+}
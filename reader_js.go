@@ -0,0 +1,97 @@
+//go:build js
+// +build js
+
+package gomcli
+
+import (
+	"errors"
+	"io"
+	"syscall/js"
+)
+
+var errPromptAborted = errors.New("Prompt aborted")
+
+// newLineReader returns, on js/wasm, a lineReader using the JS functions
+// globalThis.gomcliReadLine and globalThis.gomcliWrite, see SetJSCallbacks.
+func newLineReader(c *GomCLI) lineReader {
+	write := js.Global().Get("gomcliWrite")
+	if write.Type() == js.TypeFunction {
+		c.session.setOutput(jsWriter{write})
+	}
+	return newJSReader(js.Global().Get("gomcliReadLine"))
+}
+
+// SetJSCallbacks sets the JS functions the CLI uses for input and output when
+// running on js/wasm, e.g. to drive a terminal emulator in a web page.
+// readLine is called with the prompt and must return the line entered, or a
+// Promise resolving to it, or null when there is no more input. write is called
+// with the text to display. By default, the functions globalThis.gomcliReadLine
+// and globalThis.gomcliWrite are used if defined.
+func (c *GomCLI) SetJSCallbacks(readLine js.Value, write js.Value) {
+	c.lr = newJSReader(readLine)
+
+	c.session.setOutput(jsWriter{write})
+}
+
+type jsReader struct {
+	*dumbReader
+	readLine js.Value
+}
+
+func newJSReader(readLine js.Value) *jsReader {
+	return &jsReader{dumbReader: newDumbReader(nil, io.Discard), readLine: readLine}
+}
+
+func (r *jsReader) Prompt(prompt string) (string, error) {
+	if r.readLine.Type() != js.TypeFunction {
+		return "", io.EOF
+	}
+
+	result := r.readLine.Invoke(prompt)
+	if result.Type() == js.TypeObject && result.Get("then").Type() == js.TypeFunction {
+		result = await(result)
+	}
+	if result.IsNull() || result.IsUndefined() {
+		return "", io.EOF
+	}
+	return result.String(), nil
+}
+
+func (r *jsReader) PasswordPrompt(prompt string) (string, error) {
+	return r.Prompt(prompt)
+}
+
+// await blocks until promise settles, returning its value, or null if it is
+// rejected.
+func await(promise js.Value) js.Value {
+	done := make(chan js.Value, 1)
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) > 0 {
+			done <- args[0]
+		} else {
+			done <- js.Undefined()
+		}
+		return nil
+	})
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- js.Null()
+		return nil
+	})
+	defer onResolve.Release()
+	defer onReject.Release()
+
+	promise.Call("then", onResolve, onReject)
+	return <-done
+}
+
+type jsWriter struct {
+	write js.Value
+}
+
+func (w jsWriter) Write(p []byte) (int, error) {
+	if w.write.Type() != js.TypeFunction {
+		return len(p), nil
+	}
+	w.write.Invoke(string(p))
+	return len(p), nil
+}
@@ -0,0 +1,23 @@
+//go:build !gomcli_noliner && !js
+// +build !gomcli_noliner,!js
+
+package gomcli
+
+import (
+	"os"
+
+	"github.com/peterh/liner"
+)
+
+var errPromptAborted = liner.ErrPromptAborted
+
+func newLineReader(c *GomCLI) lineReader {
+	if !isTerminalFile(os.Stdin) {
+		return newBatchReader(c)
+	}
+
+	lr := liner.NewLiner()
+	lr.SetWordCompleter(c.complete)
+	lr.SetTabCompletionStyle(liner.TabPrints)
+	return lr
+}
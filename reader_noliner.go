@@ -0,0 +1,18 @@
+//go:build gomcli_noliner && !js
+// +build gomcli_noliner,!js
+
+package gomcli
+
+import (
+	"errors"
+	"os"
+)
+
+var errPromptAborted = errors.New("Prompt aborted")
+
+func newLineReader(c *GomCLI) lineReader {
+	if !isTerminalFile(os.Stdin) {
+		return newBatchReader(c)
+	}
+	return newDumbReader(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,110 @@
+package gomcli
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redactionMask = "****"
+
+// AddRedaction registers a pattern whose matches are masked when input is
+// stored in the history. If the pattern contains capturing groups, only the
+// text matched by the groups is masked, e.g. `token=(\S+)`. Commands still
+// receive the real values.
+func (c *GomCLI) AddRedaction(pattern *regexp.Regexp) {
+	c.redactions = append(c.redactions, pattern)
+}
+
+// redact returns input with registered patterns and the SecretArgs of the
+// Commands it invokes masked.
+func (c *GomCLI) redact(input string) string {
+	input = c.redactText(input)
+
+	lines, err := c.parse(input)
+	if err != nil {
+		return input
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, line := range lines {
+		for _, tok := range c.secretTokens(line) {
+			b.WriteString(input[last:tok.Start])
+			b.WriteString(redactionMask)
+			last = tok.End
+		}
+	}
+	b.WriteString(input[last:])
+	return b.String()
+}
+
+// traceLine returns the words of line as echoed by the trace, with the
+// registered patterns and the SecretArgs of the Command it invokes masked.
+func (c *GomCLI) traceLine(line ParsedLine) string {
+	return c.redactText(quoteWords(c.maskSecrets(line)))
+}
+
+// maskSecrets returns the words of line with the SecretArgs of the Command it
+// invokes masked.
+func (c *GomCLI) maskSecrets(line ParsedLine) []string {
+	words := line.Values()
+	for _, secret := range c.secretTokens(line) {
+		for i, tok := range line.Tokens {
+			if tok.Start == secret.Start {
+				words[i] = redactionMask
+			}
+		}
+	}
+	return words
+}
+
+// redactText returns text with the matches of the registered patterns masked.
+func (c *GomCLI) redactText(text string) string {
+	for _, re := range c.redactions {
+		text = redactPattern(re, text)
+	}
+	return text
+}
+
+// secretTokens returns the Tokens of line passed as SecretArgs to the Command
+// it invokes, in order.
+func (c *GomCLI) secretTokens(line ParsedLine) (secrets []Token) {
+	line = c.stripAssignments(line)
+	cmd, i := c.lookupCommand(line.Values())
+	if cmd == nil {
+		return
+	}
+	for j := i; j < len(line.Tokens); j++ {
+		for _, idx := range cmd.SecretArgs {
+			if idx == j-i {
+				secrets = append(secrets, line.Tokens[j])
+				break
+			}
+		}
+	}
+	return
+}
+
+func redactPattern(re *regexp.Regexp, s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		groups := [][]int{m[:2]}
+		if len(m) > 2 {
+			groups = groups[:0]
+			for g := 2; g < len(m); g += 2 {
+				groups = append(groups, m[g:g+2])
+			}
+		}
+		for _, g := range groups {
+			if g[0] < last {
+				continue
+			}
+			b.WriteString(s[last:g[0]])
+			b.WriteString(redactionMask)
+			last = g[1]
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
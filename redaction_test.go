@@ -0,0 +1,29 @@
+package gomcli
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTraceMasksSecrets(t *testing.T) {
+	var out bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.SetTrace(true)
+	c.AddRedaction(regexp.MustCompile(`token=(\S+)`))
+	c.AddCommand(Command{
+		Name:       "login",
+		Function:   func(user, password string, opts ...string) {},
+		SecretArgs: []int{1},
+	})
+
+	c.processInput("login admin hunter2 token=abc")
+	got := out.String()
+	if strings.Contains(got, "hunter2") || strings.Contains(got, "abc") {
+		t.Errorf("trace = %q, want secrets masked", got)
+	}
+	if want := "+ login admin **** token=****\n"; got != want {
+		t.Errorf("trace = %q, want %q", got, want)
+	}
+}
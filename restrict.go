@@ -0,0 +1,79 @@
+package gomcli
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCliCommandNotAllowed is passed to the ErrHandler of a Command, wrapped,
+// when the Restrictions in effect do not allow it to run.
+var ErrCliCommandNotAllowed = errors.New("Command not allowed")
+
+// Restrictions limits what can be run through the CLI, e.g. while running
+// untrusted scripts, and is enforced when dispatching each Command. The zero
+// value does not restrict anything.
+type Restrictions struct {
+	// Allow, if not nil, lists the names of the only Commands that can run.
+	Allow []string
+
+	// NoFiles prevents the built-in Commands that read or write files, such
+	// as "export last", from running.
+	NoFiles bool
+
+	// NoPipes prevents piping the output of Commands to external programs,
+	// if enabled with EnableShellPipes.
+	NoPipes bool
+
+	// NoAssignments prevents "NAME=value" words from setting variables, if
+	// enabled with EnableAssignments, and the built-in "export" Command from
+	// running. The words are also prevented when Allow is not nil.
+	NoAssignments bool
+}
+
+func (r *Restrictions) allows(cmd *Command) bool {
+	if r.NoFiles && cmd.files {
+		return false
+	}
+	if r.NoAssignments && cmd.assigns {
+		return false
+	}
+	if r.Allow == nil {
+		return true
+	}
+	for _, name := range r.Allow {
+		if name == cmd.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRestrictions sets the Restrictions enforced when dispatching Commands. A
+// nil value lifts them.
+func (c *GomCLI) SetRestrictions(r *Restrictions) {
+	c.restrictions = nil
+	if r != nil {
+		c.restrictions = []Restrictions{*r}
+	}
+}
+
+// Restricted calls fn with r enforced, on top of the Restrictions already in
+// effect, which are restored when fn returns.
+func (c *GomCLI) Restricted(r Restrictions, fn func() error) error {
+	c.restrictions = append(c.restrictions, r)
+	defer func() {
+		c.restrictions = c.restrictions[:len(c.restrictions)-1]
+	}()
+	return fn()
+}
+
+// checkRestrictions returns an error wrapping ErrCliCommandNotAllowed if any
+// of the Restrictions in effect does not allow cmd to run.
+func (c *GomCLI) checkRestrictions(cmd *Command) error {
+	for i := range c.restrictions {
+		if !c.restrictions[i].allows(cmd) {
+			return fmt.Errorf("%w: %q", ErrCliCommandNotAllowed, cmd.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package gomcli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ScriptError is returned by RunScript and RunReader when a line of the script
+// fails, wrapping the error returned for it.
+type ScriptError struct {
+	Name       string
+	LineNumber int
+	Err        error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("%v:%d: %v", e.Name, e.LineNumber, e.Err)
+}
+
+// Unwrap returns the error returned for the line of the script.
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// SetScriptContinueOnError sets whether RunScript and RunReader go on with the
+// rest of the script when a line fails, printing the error, instead of
+// stopping. The default is false.
+func (c *GomCLI) SetScriptContinueOnError(value bool) {
+	c.scriptContinue = value
+}
+
+// RunScript runs the commands in the file at path, once verified if a
+// ScriptVerifier is set, see RunReader.
+func (c *GomCLI) RunScript(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := c.verifyScript(path, content); err != nil {
+		return err
+	}
+	return c.run(path, bytes.NewReader(content))
+}
+
+// RunReader runs the commands read from r line by line, as if entered at the
+// prompt, without adding them to the history. Blank lines and lines starting
+// with "#" are skipped, and lines ending in a backslash continue on the next
+// one, as do lines with unterminated quotes if SetContinueOnOpenQuote is
+// enabled; otherwise they fail with a *ParseError. Errors returned by Commands
+// stop the script, unless SetScriptContinueOnError is enabled, in which case
+// the first one is returned once the script completes. The LineNumber of a
+// ScriptError is the one the failed command starts at.
+func (c *GomCLI) RunReader(r io.Reader) error {
+	return c.run("input", r)
+}
+
+func (c *GomCLI) run(name string, r io.Reader) error {
+	prevExit := c.exitOnCmdError
+	c.exitOnCmdError = true
+	defer func() {
+		c.exitOnCmdError = prevExit
+	}()
+
+	var first error
+	var input string
+	start, n := 0, 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if input == "" {
+			start = n
+			if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' {
+				continue
+			}
+		}
+
+		if continuesLine(line) {
+			input += line[:len(line)-1]
+			continue
+		}
+		input += line
+		if c.continueQuotes && c.hasOpenQuote(input) {
+			input += "\n"
+			continue
+		}
+
+		err := c.processInput(input)
+		input = ""
+		if c.stopRequested() {
+			return first
+		}
+		if err == nil {
+			continue
+		}
+		err = &ScriptError{Name: name, LineNumber: start, Err: err}
+		if !c.scriptContinue {
+			return err
+		}
+		c.errorf("[-] %v\n", err)
+		if first == nil {
+			first = err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if input != "" {
+		if err := c.processInput(input); err != nil {
+			err = &ScriptError{Name: name, LineNumber: start, Err: err}
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
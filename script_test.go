@@ -0,0 +1,38 @@
+package gomcli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunReaderOpenQuote(t *testing.T) {
+	script := "say one\nsay 'two\nsay three\nsay x'\n"
+	for _, continueQuotes := range []bool{false, true} {
+		c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+		c.SetContinueOnOpenQuote(continueQuotes)
+		var said []string
+		c.AddCommand(Command{Name: "say", Function: func(word string) {
+			said = append(said, word)
+		}})
+
+		err := c.RunReader(strings.NewReader(script))
+		if continueQuotes {
+			if want := []string{"one", "two\nsay three\nsay x"}; err != nil || !reflect.DeepEqual(said, want) {
+				t.Errorf("continued: said %q, %v, want %q", said, err, want)
+			}
+			continue
+		}
+
+		var serr *ScriptError
+		var perr *ParseError
+		if !errors.As(err, &serr) || serr.LineNumber != 2 || !errors.As(err, &perr) {
+			t.Errorf("RunReader = %v, want a ParseError at line 2", err)
+		}
+		if want := []string{"one"}; !reflect.DeepEqual(said, want) {
+			t.Errorf("said %q, want %q", said, want)
+		}
+	}
+}
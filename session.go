@@ -0,0 +1,259 @@
+package gomcli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Session represents the state of the interaction with a GomCLI that is shared
+// with Commands. A Command's Function receives the current *Session if it is
+// declared as its first parameter, e.g. func(s *gomcli.Session, name string).
+type Session struct {
+	cli     *GomCLI
+	outMu   sync.Mutex
+	out     io.Writer
+	ctx     context.Context
+	cancel  context.CancelFunc
+	current context.Context
+	in      *lineQueue
+	expect  *expectBuffer
+	line    ParsedLine
+
+	mu         sync.RWMutex
+	vars       map[string]string
+	lastResult interface{}
+	status     int
+	workspace  *Workspace
+	dir        string
+	dirs       []string
+	tempDir    string
+	notes      []string
+}
+
+func newSession(cli *GomCLI) *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+	dir, _ := os.Getwd()
+	return &Session{
+		cli:     cli,
+		out:     os.Stdout,
+		ctx:     ctx,
+		cancel:  cancel,
+		current: ctx,
+		vars:    make(map[string]string),
+		dir:     dir,
+	}
+}
+
+// CLI returns the GomCLI the Session belongs to.
+func (s *Session) CLI() *GomCLI {
+	return s.cli
+}
+
+// Context returns a context that is cancelled when the CLI is closed or, while
+// a Streaming Command is running, when the user presses Ctrl-C.
+func (s *Session) Context() context.Context {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// interruptible replaces the Session context with one that is also cancelled on
+// Ctrl-C, until the returned function is called.
+func (s *Session) interruptible() func() {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	s.mu.Lock()
+	s.current = ctx
+	s.mu.Unlock()
+
+	return func() {
+		signal.Stop(sig)
+		cancel()
+
+		s.mu.Lock()
+		s.current = s.ctx
+		s.mu.Unlock()
+	}
+}
+
+// Line returns the ParsedLine of the Command being executed, e.g. for Commands
+// that need the arguments as typed by the user.
+func (s *Session) Line() ParsedLine {
+	return s.line
+}
+
+// IsTerminal reports whether the Session output is an interactive terminal.
+func (s *Session) IsTerminal() bool {
+	s.outMu.Lock()
+	f, ok := s.out.(*os.File)
+	s.outMu.Unlock()
+	return ok && isTerminalFile(f)
+}
+
+// Var returns the value of the session variable name, and whether it is set.
+func (s *Session) Var(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.vars[name]
+	return value, ok
+}
+
+// SetVar sets the session variable name to value.
+func (s *Session) SetVar(name string, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars[name] = value
+}
+
+// UnsetVar removes the session variable name.
+func (s *Session) UnsetVar(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.vars, name)
+}
+
+// Vars returns a copy of the session variables.
+func (s *Session) Vars() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vars := make(map[string]string, len(s.vars))
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// Print is a wrapper over fmt.Fprint to the Session output, thread-safe with
+// the rest of the gomcli printer functions.
+func (s *Session) Print(a ...interface{}) (n int, err error) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	return fmt.Fprint(s.out, a...)
+}
+
+// Printf is a wrapper over fmt.Fprintf to the Session output, thread-safe with
+// the rest of the gomcli printer functions.
+func (s *Session) Printf(format string, a ...interface{}) (n int, err error) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	return fmt.Fprintf(s.out, format, a...)
+}
+
+// Println is a wrapper over fmt.Fprintln to the Session output, thread-safe with
+// the rest of the gomcli printer functions.
+func (s *Session) Println(a ...interface{}) (n int, err error) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	return fmt.Fprintln(s.out, a...)
+}
+
+// Notify queues a message, formatted as with fmt.Sprintln, to be printed above
+// the next prompt, e.g. from a goroutine that should not print while the user
+// is typing. It is safe for concurrent use.
+func (s *Session) Notify(a ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notes = append(s.notes, fmt.Sprintln(a...))
+}
+
+// printNotes prints the messages queued with Notify.
+func (s *Session) printNotes() {
+	s.mu.Lock()
+	notes := s.notes
+	s.notes = nil
+	s.mu.Unlock()
+
+	for _, note := range notes {
+		s.Print(note)
+	}
+}
+
+// Writer returns an io.Writer to the Session output, for Commands that stream
+// large amounts of output. Each Write goes straight to the current output
+// without intermediate buffering, following any redirection set by Capture.
+func (s *Session) Writer() io.Writer {
+	return sessionWriter{s}
+}
+
+type sessionWriter struct {
+	s *Session
+}
+
+func (w sessionWriter) Write(p []byte) (int, error) {
+	w.s.outMu.Lock()
+	defer w.s.outMu.Unlock()
+	return w.s.out.Write(p)
+}
+
+// Capture calls fn, redirecting into a buffer the output printed during the
+// call through the Session and the gomcli printer functions, and returns it
+// along with the error returned by fn.
+func (s *Session) Capture(fn func() error) (string, error) {
+	var buf bytes.Buffer
+	restore := s.redirect(&buf)
+	defer restore()
+
+	err := fn()
+	return buf.String(), err
+}
+
+// redirect sends the output printed through the Session and the gomcli printer
+// functions to w, returning a function that restores the previous output. The
+// printer functions follow the redirection started last that is still active,
+// whichever Session it belongs to.
+func (s *Session) redirect(w io.Writer) (restore func()) {
+	s.outMu.Lock()
+	prevOut := s.out
+	s.out = w
+	s.outMu.Unlock()
+
+	r := &redirection{syncWriter{&s.outMu, w}}
+	lock.Lock()
+	redirections = append(redirections, r)
+	lock.Unlock()
+
+	return func() {
+		s.outMu.Lock()
+		s.out = prevOut
+		s.outMu.Unlock()
+
+		lock.Lock()
+		removeRedirection(r)
+		lock.Unlock()
+	}
+}
+
+// setOutput sets the output of the Session, which the package-level printer
+// functions follow as well.
+func (s *Session) setOutput(w io.Writer) {
+	s.outMu.Lock()
+	s.out = w
+	s.outMu.Unlock()
+
+	lock.Lock()
+	output = syncWriter{&s.outMu, w}
+	lock.Unlock()
+}
+
+func (s *Session) close() {
+	s.cancel()
+	s.removeTemp()
+	if s.in != nil {
+		s.in.Close()
+	}
+}
@@ -0,0 +1,68 @@
+package gomcli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentCaptures(t *testing.T) {
+	var base bytes.Buffer
+	clis := []*GomCLI{
+		NewWithIO(&bytes.Buffer{}, &bytes.Buffer{}),
+		NewWithIO(&bytes.Buffer{}, &base),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range clis {
+		wg.Add(1)
+		go func(i int, s *Session) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				want := fmt.Sprintf("cli %d line %d\n", i, j)
+				out, _ := s.Capture(func() error {
+					s.Print(want)
+					return nil
+				})
+				if out != want {
+					t.Errorf("Capture = %q, want %q", out, want)
+					return
+				}
+			}
+		}(i, c.Session())
+	}
+	wg.Wait()
+
+	if len(redirections) != 0 {
+		t.Fatalf("%d redirections left", len(redirections))
+	}
+	Print("after")
+	if got := base.String(); !strings.HasSuffix(got, "after") {
+		t.Errorf("printer output = %q, want it to end with %q", got, "after")
+	}
+}
+
+func TestNestedCapturesOfTwoCLIs(t *testing.T) {
+	var base bytes.Buffer
+	a := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	b := NewWithIO(&bytes.Buffer{}, &base)
+
+	var restoreB func()
+	outA, _ := a.Session().Capture(func() error {
+		var bufB bytes.Buffer
+		restoreB = b.Session().redirect(&bufB)
+		return nil
+	})
+	Print("b")
+	restoreB()
+	Print("base")
+
+	if outA != "" {
+		t.Errorf("a captured %q", outA)
+	}
+	if got := base.String(); got != "base" {
+		t.Errorf("base output = %q, want %q", got, "base")
+	}
+}
@@ -0,0 +1,70 @@
+package gomcli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+const (
+	// StatusSucceeded is the Status of a command run without errors.
+	StatusSucceeded = 0
+	// StatusFailed is the Status of a command that failed, or of input that
+	// could not be parsed.
+	StatusFailed = 1
+	// StatusNotFound is the Status of input invoking no Command.
+	StatusNotFound = 127
+)
+
+// SetCaptureOutput sets whether the output each Command prints through the
+// Session and the gomcli printer functions is also kept, without its trailing
+// newlines, in the session variable LAST_OUTPUT, e.g. to expand it as
+// "$LAST_OUTPUT" in the next command. Streaming Commands are not captured. The
+// default is false.
+func (c *GomCLI) SetCaptureOutput(value bool) {
+	c.captureOutput = value
+}
+
+// Status returns the exit status of the last command run, as expanded by "$?"
+// if enabled with SetExpandVariables, and by "{?}" in the prompt template: one
+// of StatusSucceeded, StatusFailed or StatusNotFound.
+func (s *Session) Status() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *Session) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// exitStatus returns the Status of a command run with the given result.
+func exitStatus(err error) int {
+	if err != nil {
+		return StatusFailed
+	}
+	return StatusSucceeded
+}
+
+// capture calls fn, which runs cmd, keeping its output in LAST_OUTPUT if
+// enabled with SetCaptureOutput.
+func (c *GomCLI) capture(cmd *Command, fn func() error) error {
+	if !c.captureOutput || cmd.Streaming || cmd.keepsOutput {
+		return fn()
+	}
+
+	s := c.session
+	var buf bytes.Buffer
+	s.outMu.Lock()
+	w := io.MultiWriter(s.out, &buf)
+	s.outMu.Unlock()
+
+	restore := s.redirect(w)
+	defer func() {
+		restore()
+		s.SetVar("LAST_OUTPUT", strings.TrimRight(buf.String(), "\n"))
+	}()
+	return fn()
+}
@@ -0,0 +1,108 @@
+package gomcli
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of names passed to SuggestionHandler.
+const maxSuggestions = 3
+
+// SuggestionHandler is like NotFoundHandler, but also receives the names of
+// the Commands close to the input, closest first, e.g. to print "unknown
+// command "staus", did you mean "status"?". suggestions is empty if there are
+// none.
+type SuggestionHandler func(name string, suggestions []string) error
+
+// SetSuggestionHandler sets the function called when the provided input does
+// not match any known Command, along with the names returned by Suggest for
+// it. It takes precedence over the NotFoundHandler.
+func (c *GomCLI) SetSuggestionHandler(function SuggestionHandler) {
+	c.suggestionHandler = function
+}
+
+// Suggest returns the names of up to 3 Commands close to the words of input,
+// closest first: those within a small edit distance, ignoring case, each
+// insertion, deletion, substitution or transposition of adjacent characters
+// counting as one. The distance allowed grows with the length of the names.
+func (c *GomCLI) Suggest(input []string) []string {
+	type suggestion struct {
+		name string
+		dist int
+	}
+	var res []suggestion
+	for _, name := range c.contextualComplete() {
+		n := strings.Count(name, " ") + 1
+		if n > len(input) {
+			continue
+		}
+		typed := strings.Join(input[:n], " ")
+		dist := editDistance(strings.ToLower(typed), strings.ToLower(name))
+		if dist <= maxEditDistance(name) {
+			res = append(res, suggestion{name, dist})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].dist != res[j].dist {
+			return res[i].dist < res[j].dist
+		}
+		return res[i].name < res[j].name
+	})
+
+	if len(res) > maxSuggestions {
+		res = res[:maxSuggestions]
+	}
+	names := make([]string, len(res))
+	for i, s := range res {
+		names[i] = s.name
+	}
+	return names
+}
+
+// maxEditDistance returns the edit distance up to which name is suggested.
+func maxEditDistance(name string) int {
+	if n := len([]rune(name)) / 3; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// i.e. the Levenshtein distance also counting transpositions of adjacent
+// runes as one edit.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between s[:i] and t[:j].
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
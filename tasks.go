@@ -0,0 +1,109 @@
+package gomcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TaskRenderer renders the status of a number of concurrent tasks, one line
+// each, updating the lines in place as tasks progress. It is safe for
+// concurrent use. When the output is not a terminal, each update is printed as
+// a new line instead.
+type TaskRenderer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	inPlace  bool
+	names    []string
+	statuses []string
+	drawn    bool
+}
+
+// NewTaskRenderer returns a TaskRenderer writing to w, usually Session.Writer,
+// with one line for each of the given task names.
+func NewTaskRenderer(w io.Writer, names ...string) *TaskRenderer {
+	return &TaskRenderer{
+		w:        w,
+		inPlace:  isTerminalWriter(w),
+		names:    names,
+		statuses: make([]string, len(names)),
+	}
+}
+
+// Update sets the status of the i-th task and redraws it.
+func (r *TaskRenderer) Update(i int, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i < 0 || i >= len(r.names) {
+		return
+	}
+	r.statuses[i] = status
+
+	if !r.inPlace {
+		fmt.Fprintln(r.w, r.line(i))
+		return
+	}
+	r.redraw()
+}
+
+// Progress sets the status of the i-th task to a progress bar showing done out
+// of total, followed by status.
+func (r *TaskRenderer) Progress(i int, done int, total int, status string) {
+	r.Update(i, progressBar(done, total, 20)+" "+status)
+}
+
+// Render draws all the tasks, e.g. before any of them has reported a status.
+func (r *TaskRenderer) Render() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.inPlace {
+		for i := range r.names {
+			fmt.Fprintln(r.w, r.line(i))
+		}
+		return
+	}
+	r.redraw()
+}
+
+func (r *TaskRenderer) redraw() {
+	var b strings.Builder
+	if r.drawn {
+		fmt.Fprintf(&b, "\x1b[%dA", len(r.names))
+	}
+	for i := range r.names {
+		b.WriteString("\r\x1b[2K")
+		b.WriteString(r.line(i))
+		b.WriteString("\n")
+	}
+	r.drawn = true
+	io.WriteString(r.w, b.String())
+}
+
+func (r *TaskRenderer) line(i int) string {
+	return r.names[i] + ": " + r.statuses[i]
+}
+
+func progressBar(done int, total int, width int) string {
+	if total <= 0 {
+		return "[" + strings.Repeat("-", width) + "]"
+	}
+	done = clamp(done, 0, total)
+	filled := clamp(done*width/total, 0, width)
+	return fmt.Sprintf("[%v%v] %3d%%", strings.Repeat("#", filled),
+		strings.Repeat("-", width-filled), done*100/total)
+}
+
+func isTerminalWriter(w io.Writer) bool {
+	switch w := w.(type) {
+	case sessionWriter:
+		return w.s.IsTerminal()
+	case *os.File:
+		return isTerminalFile(w)
+	default:
+		return false
+	}
+}
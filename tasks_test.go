@@ -0,0 +1,19 @@
+package gomcli
+
+import "testing"
+
+func TestProgressBarClamps(t *testing.T) {
+	for _, tc := range []struct {
+		done, total int
+		want        string
+	}{
+		{-3, 10, "[----]   0%"},
+		{5, 10, "[##--]  50%"},
+		{15, 10, "[####] 100%"},
+		{1, 0, "[----]"},
+	} {
+		if got := progressBar(tc.done, tc.total, 4); got != tc.want {
+			t.Errorf("progressBar(%d, %d, 4) = %q, want %q", tc.done, tc.total, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package gomcli
+
+import "os"
+
+// TempFile creates a new temporary file for the Session, opened for reading and
+// writing, as os.CreateTemp does with pattern. It is removed along with the
+// rest of the temporary files and directories of the Session when the CLI is
+// closed.
+func (s *Session) TempFile(pattern string) (*os.File, error) {
+	dir, err := s.tempRoot()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, pattern)
+}
+
+// TempDir creates a new temporary directory for the Session, as os.MkdirTemp
+// does with pattern, and returns its path. It is removed along with its
+// content when the CLI is closed.
+func (s *Session) TempDir(pattern string) (string, error) {
+	dir, err := s.tempRoot()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, pattern)
+}
+
+// tempRoot returns the directory holding the temporary files of the Session,
+// creating it on first use.
+func (s *Session) tempRoot() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tempDir == "" {
+		dir, err := os.MkdirTemp("", "gomcli-")
+		if err != nil {
+			return "", err
+		}
+		s.tempDir = dir
+	}
+	return s.tempDir, nil
+}
+
+// removeTemp removes the temporary files of the Session.
+func (s *Session) removeTemp() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tempDir != "" {
+		os.RemoveAll(s.tempDir)
+		s.tempDir = ""
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !windows && !linux && !freebsd && !netbsd && !openbsd
+// +build !darwin,!windows,!linux,!freebsd,!netbsd,!openbsd
+
+package gomcli
+
+import "os"
+
+// terminalColumns returns 0, as the width of terminals is not known on this
+// platform.
+func terminalColumns(f *os.File) int {
+	return 0
+}
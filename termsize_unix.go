@@ -0,0 +1,25 @@
+//go:build darwin || linux || freebsd || netbsd || openbsd
+// +build darwin linux freebsd netbsd openbsd
+
+package gomcli
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// terminalColumns returns the width of the terminal f, or 0 if unknown.
+func terminalColumns(f *os.File) int {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.cols)
+}
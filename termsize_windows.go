@@ -0,0 +1,29 @@
+//go:build windows
+// +build windows
+
+package gomcli
+
+import (
+	"os"
+	"unsafe"
+)
+
+var procGetConsoleScreenBufferInfo = modKernel32.NewProc("GetConsoleScreenBufferInfo")
+
+type consoleScreenBufferInfo struct {
+	size              [2]int16
+	cursorPosition    [2]int16
+	attributes        uint16
+	window            [4]int16
+	maximumWindowSize [2]int16
+}
+
+// terminalColumns returns the width of the console f, or 0 if unknown.
+func terminalColumns(f *os.File) int {
+	var info consoleScreenBufferInfo
+	r, _, _ := procGetConsoleScreenBufferInfo.Call(f.Fd(), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return 0
+	}
+	return int(info.window[2]-info.window[0]) + 1
+}
@@ -0,0 +1,171 @@
+package gomcli
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Cmd0 returns a Command with the given name that calls fn, which takes no
+// arguments. Like the rest of the typed constructors, the Command does not rely
+// on reflection when invoked, and errors returned by fn are handled as errors
+// returned by a Function.
+func Cmd0(name string, fn func() error) Command {
+	return Command{
+		Name: name,
+		typed: func(s *Session, args []string) error {
+			if err := checkArgCount(name, args, 0); err != nil {
+				return err
+			}
+			return fn()
+		},
+	}
+}
+
+// Cmd1 returns a Command with the given name that calls fn with its argument
+// converted to T1.
+func Cmd1[T1 any](name string, fn func(T1) error) Command {
+	t1 := typeOf[T1]()
+	return Command{
+		Name:      name,
+		signature: []reflect.Type{t1},
+		typed: func(s *Session, args []string) error {
+			if err := checkArgCount(name, args, 1); err != nil {
+				return err
+			}
+			a1, err := parseArg[T1](s, name, 0, args, t1)
+			if err != nil {
+				return err
+			}
+			return fn(a1)
+		},
+	}
+}
+
+// Cmd2 returns a Command with the given name that calls fn with its arguments
+// converted to T1 and T2.
+func Cmd2[T1, T2 any](name string, fn func(T1, T2) error) Command {
+	t1, t2 := typeOf[T1](), typeOf[T2]()
+	return Command{
+		Name:      name,
+		signature: []reflect.Type{t1, t2},
+		typed: func(s *Session, args []string) error {
+			if err := checkArgCount(name, args, 2); err != nil {
+				return err
+			}
+			a1, err := parseArg[T1](s, name, 0, args, t1)
+			if err != nil {
+				return err
+			}
+			a2, err := parseArg[T2](s, name, 1, args, t2)
+			if err != nil {
+				return err
+			}
+			return fn(a1, a2)
+		},
+	}
+}
+
+// Cmd3 returns a Command with the given name that calls fn with its arguments
+// converted to T1, T2 and T3.
+func Cmd3[T1, T2, T3 any](name string, fn func(T1, T2, T3) error) Command {
+	t1, t2, t3 := typeOf[T1](), typeOf[T2](), typeOf[T3]()
+	return Command{
+		Name:      name,
+		signature: []reflect.Type{t1, t2, t3},
+		typed: func(s *Session, args []string) error {
+			if err := checkArgCount(name, args, 3); err != nil {
+				return err
+			}
+			a1, err := parseArg[T1](s, name, 0, args, t1)
+			if err != nil {
+				return err
+			}
+			a2, err := parseArg[T2](s, name, 1, args, t2)
+			if err != nil {
+				return err
+			}
+			a3, err := parseArg[T3](s, name, 2, args, t3)
+			if err != nil {
+				return err
+			}
+			return fn(a1, a2, a3)
+		},
+	}
+}
+
+// typeOf returns the type T, computed once by the typed constructors so that
+// their Commands do not need to when invoked.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func checkArgCount(name string, args []string, n int) error {
+	if len(args) > n {
+		return &ArgumentError{Cmd: name, Index: n, Token: args[n], Err: ErrCmdInvalidArgs}
+	}
+	return nil
+}
+
+// parseArg converts the i-th argument to T, which is the type want, handling
+// the most common types directly, unless a Converter is registered for them,
+// and falling back to the conversions of GomCLI for the rest.
+func parseArg[T any](s *Session, name string, i int, args []string, want reflect.Type) (T, error) {
+	var zero T
+	if i >= len(args) {
+		return zero, &ArgumentError{
+			Cmd:   name,
+			Index: i,
+			Want:  want,
+			Err:   ErrCmdMissingArgs,
+		}
+	}
+
+	var v interface{}
+	var err error
+	var kind interface{} = zero
+	if _, ok := s.cli.converters[want]; ok {
+		// Registered Converters take precedence, see convertArg.
+		kind = nil
+	}
+	switch kind.(type) {
+	case string:
+		v = args[i]
+	case int:
+		var n int64
+		n, err = strconv.ParseInt(args[i], 0, strconv.IntSize)
+		v = int(n)
+	case int64:
+		v, err = strconv.ParseInt(args[i], 0, 64)
+	case uint:
+		var n uint64
+		n, err = strconv.ParseUint(args[i], 0, strconv.IntSize)
+		v = uint(n)
+	case float64:
+		v, err = strconv.ParseFloat(args[i], 64)
+	case bool:
+		v, err = strconv.ParseBool(args[i])
+	case time.Duration:
+		v, err = time.ParseDuration(args[i])
+	default:
+		var rv reflect.Value
+		rv, err = s.cli.convertArg(want, args[i])
+		if err == nil {
+			v = rv.Interface()
+		}
+	}
+
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			err = ErrCmdArgOverflow
+		}
+		return zero, &ArgumentError{
+			Cmd:   name,
+			Index: i,
+			Token: args[i],
+			Want:  want,
+			Err:   err,
+		}
+	}
+	return v.(T), nil
+}
@@ -0,0 +1,25 @@
+package gomcli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypedCommandUsesRegisteredConverter(t *testing.T) {
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.RegisterConverter(reflect.TypeOf(""), func(s string) (interface{}, error) {
+		return strings.ToUpper(s), nil
+	})
+	var got string
+	c.AddCommand(Cmd1("greet", func(name string) error {
+		got = name
+		return nil
+	}))
+
+	c.processInput("greet world")
+	if got != "WORLD" {
+		t.Errorf("got %q, want %q", got, "WORLD")
+	}
+}
@@ -0,0 +1,193 @@
+package gomcli
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrCliInvalidCommand is wrapped by the errors returned from Validate for each
+// problem found in the registered Commands.
+var ErrCliInvalidCommand = errors.New("Invalid command")
+
+// Validate checks the registered Commands for problems that would otherwise
+// only show up when they are invoked: names registered more than once, missing
+// or invalid Functions, parameters of unsupported types, Redirects to unknown
+// Commands or in a cycle, and names that shadow the arguments of a shorter
+// Command, except between built-in Commands. It is meant to be called at
+// startup, after registering the Commands.
+func (c *GomCLI) Validate() []error {
+	var errs []error
+
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range c.duplicates {
+		errs = append(errs, invalidCommand(name, "registered more than once"))
+	}
+
+	for _, name := range names {
+		cmd := c.commands[name]
+		if err := c.validateCommand(&cmd); err != nil {
+			errs = append(errs, err)
+		} else if _, ok := c.commands[cmd.Redirect]; cmd.Redirect != "" && !ok {
+			errs = append(errs, invalidCommand(cmd.Name,
+				fmt.Sprintf("redirects to unknown command %q", cmd.Redirect)))
+		} else if _, err := c.followRedirects(&cmd, false); errors.Is(err, ErrCliRedirectCycle) {
+			errs = append(errs, invalidCommand(cmd.Name, err.Error()))
+		}
+	}
+
+	for _, a := range c.Ambiguities() {
+		if c.commands[a.Shorter].builtin != nil && c.commands[a.Longer].builtin != nil {
+			continue
+		}
+		errs = append(errs, invalidCommand(a.Longer,
+			fmt.Sprintf("shadows the arguments of %q", a.Shorter)))
+	}
+
+	return errs
+}
+
+// Ambiguity describes input that can be read both as the Command named Longer,
+// and as the Command named Shorter followed by arguments, e.g. "show version"
+// when "show" accepts arguments.
+type Ambiguity struct {
+	Shorter string
+	Longer  string
+}
+
+// Ambiguities reports the dispatch ambiguities among the registered Commands,
+// sorted by Shorter and then Longer. They are always resolved in favour of the
+// Command whose name matches the most leading words of the input, whether they
+// are quoted or not, so that Shorter never receives the words making up the
+// name of Longer as its first arguments.
+func (c *GomCLI) Ambiguities() []Ambiguity {
+	names := make([]string, 0, len(c.commands))
+	for name := range c.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res []Ambiguity
+	for _, name := range names {
+		cmd := c.commands[name]
+		if !acceptsArgs(&cmd) {
+			continue
+		}
+		for _, other := range names {
+			if strings.HasPrefix(other, name+" ") {
+				res = append(res, Ambiguity{Shorter: name, Longer: other})
+			}
+		}
+	}
+	return res
+}
+
+// validateCommand checks a Command on its own, regardless of the rest of the
+// registered Commands.
+func (c *GomCLI) validateCommand(cmd *Command) error {
+	if cmd.Name == "" || strings.TrimSpace(cmd.Name) != cmd.Name {
+		return invalidCommand(cmd.Name, "invalid name")
+	}
+
+	if cmd.Redirect != "" || cmd.builtin != nil {
+		return nil
+	}
+	if cmd.typed != nil {
+		for i, argType := range cmd.signature {
+			if !c.supportsType(argType) {
+				return invalidCommand(cmd.Name, fmt.Sprintf("parameter %d of unsupported type %v",
+					i, argType))
+			}
+		}
+		return nil
+	}
+	if cmd.Function == nil {
+		return invalidCommand(cmd.Name, "nil Function")
+	}
+
+	t := reflect.TypeOf(cmd.Function)
+	if t.Kind() != reflect.Func {
+		return invalidCommand(cmd.Name, "Function is not a function")
+	}
+
+	argTypes := functionArgs(t)
+	if isStructBinding(argTypes) {
+		st := argTypes[0].Elem()
+		for _, f := range structFields(st) {
+			if !c.supportsType(st.Field(f.index).Type) {
+				return invalidCommand(cmd.Name, fmt.Sprintf("field %q of unsupported type %v",
+					f.name, st.Field(f.index).Type))
+			}
+		}
+		return nil
+	}
+
+	rest := c.absorbsRest(t, argTypes)
+	for i, argType := range argTypes {
+		if rest && i == len(argTypes)-1 {
+			argType = argType.Elem()
+		}
+		if !c.supportsType(argType) {
+			return invalidCommand(cmd.Name, fmt.Sprintf("parameter %d of unsupported type %v",
+				i, argType))
+		}
+	}
+	return nil
+}
+
+// supportsType reports whether arguments can be converted to type t.
+func (c *GomCLI) supportsType(t reflect.Type) bool {
+	if _, ok := c.converters[t]; ok {
+		return true
+	}
+	if t == durationType || t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+// functionArgs returns the parameter types of a Function that are converted
+// from CLI arguments, i.e. excluding the injected ones.
+func functionArgs(t reflect.Type) []reflect.Type {
+	var argTypes []reflect.Type
+	for i := 0; i < t.NumIn(); i++ {
+		argTypes = append(argTypes, t.In(i))
+	}
+	for len(argTypes) > 0 && isInjected(argTypes[0]) {
+		argTypes = argTypes[1:]
+	}
+	return argTypes
+}
+
+// acceptsArgs reports whether a Command can receive arguments.
+func acceptsArgs(cmd *Command) bool {
+	if cmd.builtin != nil || cmd.Flags != nil || cmd.grammar != nil {
+		return true
+	}
+	if cmd.typed != nil {
+		return len(cmd.signature) > 0
+	}
+	t := reflect.TypeOf(cmd.Function)
+	if t == nil || t.Kind() != reflect.Func {
+		return false
+	}
+	completer := cmd.completer()
+	return len(functionArgs(t)) > 0 && (completer == nil || len(completer("")) == 0)
+}
+
+func invalidCommand(name string, reason string) error {
+	return fmt.Errorf("%w %q: %v", ErrCliInvalidCommand, name, reason)
+}
@@ -0,0 +1,42 @@
+package gomcli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedirectCycle(t *testing.T) {
+	var out bytes.Buffer
+	c := NewWithIO(&bytes.Buffer{}, &out)
+	c.SetExitOnCmdError(true)
+	notFound := false
+	c.SetNotFoundHandler(func(name string) error {
+		notFound = true
+		return nil
+	})
+	c.AddCommand(Command{Name: "a", Redirect: "b"})
+	c.AddCommand(Command{Name: "b", Redirect: "a"})
+
+	errs := c.Validate()
+	if len(errs) != 2 {
+		t.Fatalf("Validate = %v, want an error for each Command", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrCliInvalidCommand) || !strings.Contains(err.Error(), ErrCliRedirectCycle.Error()) {
+			t.Errorf("Validate error = %v", err)
+		}
+	}
+
+	err := c.processInput("a")
+	if !errors.Is(err, ErrCliRedirectCycle) || err.Error() != `Redirect cycle: "a" -> "b" -> "a"` {
+		t.Errorf("processInput = %v, want a redirect cycle error", err)
+	}
+	if notFound {
+		t.Error("NotFoundHandler called")
+	}
+	if got := c.Session().Status(); got != StatusFailed {
+		t.Errorf("Status = %d, want %d", got, StatusFailed)
+	}
+}
@@ -0,0 +1,189 @@
+package gomcli
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SetExpandVariables sets whether "$NAME" and "${NAME}" are replaced in each
+// command, right before running it, by the value of the session variable NAME,
+// or else of the environment variable NAME, or else by nothing. As with shells,
+// variables are not expanded within single quotes nor when the "$" is escaped
+// with a backslash, and the expanded value is never split into several words.
+// "$?" is replaced by the Session Status of the last command. The default is
+// false.
+func (c *GomCLI) SetExpandVariables(value bool) {
+	c.expandVars = value
+}
+
+// EnableVariables registers the built-in "set" and "unset" Commands to manage
+// the session variables, and enables their expansion with SetExpandVariables,
+// e.g. "set host 10.0.0.1; connect $host". The names trace and output are
+// reserved for "set trace" and "set output", see EnableTrace and EnableExport,
+// and cannot be set with "set" even if those are not enabled.
+func (c *GomCLI) EnableVariables() {
+	c.expandVars = true
+	c.AddCommand(Command{
+		Name:    "set",
+		Help:    "Set a session variable, or list them",
+		Usage:   "set [NAME value...]",
+		builtin: setBuiltin,
+	})
+	c.AddCommand(Command{
+		Name:    "unset",
+		Help:    "Remove session variables",
+		Usage:   "unset NAME...",
+		builtin: unsetBuiltin,
+	})
+}
+
+func setBuiltin(s *Session, args []string) error {
+	if len(args) == 0 {
+		vars := s.Vars()
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s.Printf("%v=%v\n", name, quoteWord(vars[name]))
+		}
+		return nil
+	}
+
+	if len(args) < 2 || !isVarName(args[0]) {
+		s.Println("Usage: set [NAME value...]")
+		return nil
+	}
+	if isReservedVar(args[0]) {
+		s.Printf("%q is reserved for \"set %v\"\n", args[0], args[0])
+		return nil
+	}
+	s.SetVar(args[0], strings.Join(args[1:], " "))
+	return nil
+}
+
+// reservedVars are the names that "set" does not take as variables, as they
+// name the built-in Commands it is a prefix of.
+var reservedVars = []string{"trace", "output"}
+
+func isReservedVar(name string) bool {
+	for _, reserved := range reservedVars {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+func unsetBuiltin(s *Session, args []string) error {
+	if len(args) == 0 {
+		s.Println("Usage: unset NAME...")
+		return nil
+	}
+	for _, name := range args {
+		s.UnsetVar(name)
+	}
+	return nil
+}
+
+// expandLine returns a copy of line with the variables of its Tokens, and of
+// the programs it is piped to, expanded.
+func (c *GomCLI) expandLine(line ParsedLine) ParsedLine {
+	line.Tokens = append([]Token(nil), line.Tokens...)
+	for i, tok := range line.Tokens {
+		if strings.Contains(tok.Raw, "$") {
+			line.Tokens[i].Value = c.expandToken(tok.Raw)
+		}
+	}
+
+	if len(line.Pipes) > 0 {
+		pipes := make([]ParsedLine, len(line.Pipes))
+		for i, p := range line.Pipes {
+			pipes[i] = c.expandLine(p)
+		}
+		line.Pipes = pipes
+	}
+	return line
+}
+
+// expandToken returns the value of a word as typed, following the same quoting
+// rules as Parse, with its variables expanded.
+func (c *GomCLI) expandToken(raw string) string {
+	var b strings.Builder
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case quote == '\'':
+			if ch == '\'' {
+				quote = 0
+			} else {
+				b.WriteByte(ch)
+			}
+		case ch == '$':
+			value, n := c.expandVar(raw[i+1:])
+			if n == 0 {
+				b.WriteByte(ch)
+			}
+			b.WriteString(value)
+			i += n
+		case quote == '"':
+			if ch == '"' {
+				quote = 0
+			} else if ch == '\\' && i+1 < len(raw) && strings.IndexByte(`"\$`, raw[i+1]) >= 0 {
+				i++
+				b.WriteByte(raw[i])
+			} else {
+				b.WriteByte(ch)
+			}
+		case ch == '\\' && i+1 < len(raw):
+			if raw[i+1] != '\n' {
+				_, size := utf8.DecodeRuneInString(raw[i+1:])
+				b.WriteString(raw[i+1 : i+1+size])
+				i += size - 1
+			}
+			i++
+		case ch == '\'' || ch == '"':
+			quote = ch
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// expandVar returns the value of the variable named at the start of s, right
+// after a "$", and the number of bytes of s making up its name, or 0 if there
+// is none.
+func (c *GomCLI) expandVar(s string) (string, int) {
+	name, n := "", 0
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !(isVarName(s[1:end]) || s[1:end] == "?") {
+			return "", 0
+		}
+		name, n = s[1:end], end+1
+	} else if strings.HasPrefix(s, "?") {
+		name, n = "?", 1
+	} else {
+		for n < len(s) && isVarName(s[:n+1]) {
+			n++
+		}
+		name = s[:n]
+	}
+	if n == 0 {
+		return "", 0
+	}
+
+	if name == "?" {
+		return strconv.Itoa(c.session.Status()), n
+	}
+	if value, ok := c.session.Var(name); ok {
+		return value, n
+	}
+	return os.Getenv(name), n
+}
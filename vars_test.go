@@ -0,0 +1,30 @@
+package gomcli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetReservedNames(t *testing.T) {
+	for _, export := range []bool{false, true} {
+		var out bytes.Buffer
+		c := NewWithIO(&bytes.Buffer{}, &out)
+		c.EnableVariables()
+		if export {
+			c.EnableExport()
+		}
+
+		c.processInput("set output csv")
+		if _, ok := c.Session().Var("output"); ok {
+			t.Errorf("export %v: output was set as a variable", export)
+		}
+		if got, want := out.String() == "", export; got != want {
+			t.Errorf("export %v: printed %q", export, out.String())
+		}
+
+		c.processInput("set host example.com")
+		if v, _ := c.Session().Var("host"); v != "example.com" {
+			t.Errorf("host = %q", v)
+		}
+	}
+}
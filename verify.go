@@ -0,0 +1,75 @@
+package gomcli
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrCliScriptNotVerified is wrapped by the errors returned when the content
+// of a script does not pass the verification set with SetScriptVerifier.
+var ErrCliScriptNotVerified = errors.New("Script not verified")
+
+// ScriptVerifier is a function that checks the content of the script at path
+// before it is run, returning an error if it must not be.
+type ScriptVerifier func(path string, content []byte) error
+
+// SetScriptVerifier sets the function that checks scripts before they are run.
+// If not set, scripts are run without verification.
+func (c *GomCLI) SetScriptVerifier(verifier ScriptVerifier) {
+	c.scriptVerifier = verifier
+}
+
+func (c *GomCLI) verifyScript(path string, content []byte) error {
+	if c.scriptVerifier == nil {
+		return nil
+	}
+	return c.scriptVerifier(path, content)
+}
+
+// VerifySHA256 returns a ScriptVerifier that only accepts scripts whose SHA-256
+// digest, in hexadecimal, is among sums.
+func VerifySHA256(sums ...string) ScriptVerifier {
+	allowed := make(map[string]bool, len(sums))
+	for _, sum := range sums {
+		allowed[strings.ToLower(sum)] = true
+	}
+	return func(path string, content []byte) error {
+		digest := sha256.Sum256(content)
+		if !allowed[hex.EncodeToString(digest[:])] {
+			return fmt.Errorf("%w: %v: digest not allowed", ErrCliScriptNotVerified, path)
+		}
+		return nil
+	}
+}
+
+// VerifyEd25519 returns a ScriptVerifier that only accepts scripts signed with
+// the private key of any of keys. The Ed25519 signature of the script is read
+// from the file with the same path and the .sig suffix, either raw or base64
+// encoded.
+func VerifyEd25519(keys ...ed25519.PublicKey) ScriptVerifier {
+	return func(path string, content []byte) error {
+		sig, err := os.ReadFile(path + ".sig")
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrCliScriptNotVerified, err)
+		}
+		if len(sig) != ed25519.SignatureSize {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+			if err != nil {
+				return fmt.Errorf("%w: %v: malformed signature", ErrCliScriptNotVerified, path)
+			}
+			sig = decoded
+		}
+		for _, key := range keys {
+			if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, content, sig) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %v: invalid signature", ErrCliScriptNotVerified, path)
+	}
+}
@@ -0,0 +1,106 @@
+package gomcli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultViewHeight = 24
+
+// View presents the contents of r page by page, in the style of less. At the
+// prompt shown after each page, Enter or "f" moves forward, "b" moves back, "g"
+// and "G" jump to the top and bottom, a number jumps to that line,
+// "/pattern" searches forward, "n" repeats the last search and "q" quits. View
+// returns when the user quits or moves past the last page.
+func (c *GomCLI) View(r io.Reader, title string) error {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	height := viewHeight() - 1
+	top, search := 0, ""
+	for {
+		end := top + height
+		if end > len(lines) {
+			end = len(lines)
+		}
+		for _, line := range lines[top:end] {
+			c.session.Println(line)
+		}
+
+		status := fmt.Sprintf("%v (%d-%d/%d) :", title, top+1, end, len(lines))
+		if title == "" {
+			status = fmt.Sprintf("(%d-%d/%d) :", top+1, end, len(lines))
+		}
+		input, err := c.lr.Prompt(status)
+		if err != nil {
+			return nil
+		}
+
+		input = strings.TrimSpace(input)
+		switch {
+		case input == "" || input == "f":
+			if end >= len(lines) {
+				return nil
+			}
+			top = end
+		case input == "q":
+			return nil
+		case input == "b":
+			top -= height
+		case input == "g":
+			top = 0
+		case input == "G":
+			top = len(lines) - height
+		case input == "n" || strings.HasPrefix(input, "/"):
+			if input != "n" {
+				search = input[1:]
+			}
+			if found := viewSearch(lines, top+1, search); found >= 0 {
+				top = found
+			} else {
+				c.session.Printf("Pattern not found: %v\n", search)
+			}
+		default:
+			if n, err := strconv.Atoi(input); err == nil {
+				top = n - 1
+			}
+		}
+
+		if top > len(lines)-1 {
+			top = len(lines) - 1
+		}
+		if top < 0 {
+			top = 0
+		}
+	}
+}
+
+func viewSearch(lines []string, from int, pattern string) int {
+	if pattern == "" {
+		return -1
+	}
+	for i := from; i < len(lines); i++ {
+		if strings.Contains(lines[i], pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+func viewHeight() int {
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 1 {
+		return n
+	}
+	return defaultViewHeight
+}
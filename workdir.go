@@ -0,0 +1,139 @@
+package gomcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir returns the working directory of the Session, which starts as the one of
+// the process and is changed with Chdir. Commands should resolve relative
+// paths against it, e.g. with Session.Abs.
+func (s *Session) Dir() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dir
+}
+
+// ShortDir returns the working directory of the Session, with the home
+// directory of the user abbreviated as "~".
+func (s *Session) ShortDir() string {
+	dir := s.Dir()
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rel := strings.TrimPrefix(dir, home+string(filepath.Separator)); rel != dir {
+		return "~" + string(filepath.Separator) + rel
+	}
+	return dir
+}
+
+// Chdir changes the working directory of the Session to dir, relative to the
+// current one, or to the home directory of the user if dir starts with "~".
+// It does not change the working directory of the process.
+func (s *Session) Chdir(dir string) error {
+	path := s.Abs(dir)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return &os.PathError{Op: "chdir", Path: path, Err: os.ErrInvalid}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path == s.dir {
+		return nil
+	}
+	dirs := s.dirs[:0]
+	for _, d := range s.dirs {
+		if d != path && d != s.dir {
+			dirs = append(dirs, d)
+		}
+	}
+	s.dirs = append(dirs, s.dir)
+	s.dir = path
+	return nil
+}
+
+// Abs returns path made absolute against the working directory of the
+// Session, expanding a leading "~" to the home directory of the user.
+func (s *Session) Abs(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(s.Dir(), path)
+}
+
+// EnableDirs registers the built-in "cd", "pwd" and "dirs" Commands, to change
+// and show the working directory of the Session and the ones previously
+// visited, most recent first. "cd -" goes back to the previous directory.
+func (c *GomCLI) EnableDirs() {
+	c.AddCommand(Command{
+		Name:    "cd",
+		Help:    "Change the working directory",
+		Usage:   "cd [dir|-]",
+		builtin: cdBuiltin,
+	})
+	c.AddCommand(Command{
+		Name:  "pwd",
+		Help:  "Show the working directory",
+		Usage: "pwd",
+		builtin: func(s *Session, args []string) error {
+			s.Println(s.Dir())
+			return nil
+		},
+	})
+	c.AddCommand(Command{
+		Name:  "dirs",
+		Help:  "Show the working directory and the ones previously visited",
+		Usage: "dirs",
+		builtin: func(s *Session, args []string) error {
+			s.mu.RLock()
+			dirs := append([]string(nil), s.dirs...)
+			s.mu.RUnlock()
+			s.Println(s.Dir())
+			for i := len(dirs) - 1; i >= 0; i-- {
+				s.Println(dirs[i])
+			}
+			return nil
+		},
+	})
+}
+
+func cdBuiltin(s *Session, args []string) error {
+	if len(args) > 1 {
+		s.Println("Usage: cd [dir|-]")
+		return nil
+	}
+
+	dir := "~"
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if dir == "-" {
+		s.mu.RLock()
+		if len(s.dirs) == 0 {
+			s.mu.RUnlock()
+			s.Println("No previous directory")
+			return nil
+		}
+		dir = s.dirs[len(s.dirs)-1]
+		s.mu.RUnlock()
+	}
+
+	if err := s.Chdir(dir); err != nil {
+		s.Println(err)
+	}
+	return nil
+}
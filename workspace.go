@@ -0,0 +1,103 @@
+package gomcli
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Workspace describes the project the CLI was started in, as found by a
+// WorkspaceDetector. Vars are set as session variables, along with Name as the
+// "workspace" variable and Root as the "workspace_root" variable. If Commands
+// or Prompt are set, a context named Name is pushed with them, see
+// PushContext, so that the CLI opens inside the project. Leaving the context,
+// e.g. with the built-in "exit" Command, closes the Workspace: its variables
+// are unset and Session.Workspace returns nil.
+type Workspace struct {
+	Name     string
+	Root     string
+	Vars     map[string]string
+	Commands []Command
+	Prompt   string
+}
+
+// WorkspaceDetector is a function that inspects the directory the CLI is
+// started in, and returns the Workspace it belongs to, or nil if none.
+type WorkspaceDetector func(dir string) *Workspace
+
+// AddWorkspaceDetector registers a WorkspaceDetector, run when the CLI starts.
+// Detectors are run in the order they were added, until one finds a Workspace.
+func (c *GomCLI) AddWorkspaceDetector(detector WorkspaceDetector) {
+	c.detectors = append(c.detectors, detector)
+}
+
+// FileDetector returns a WorkspaceDetector that finds a Workspace with the
+// given name where a file named filename exists, in the directory or any of
+// its parents, which becomes the Root of the Workspace.
+func FileDetector(name string, filename string) WorkspaceDetector {
+	return func(dir string) *Workspace {
+		for {
+			if _, err := os.Stat(filepath.Join(dir, filename)); err == nil {
+				return &Workspace{Name: name, Root: dir}
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return nil
+			}
+			dir = parent
+		}
+	}
+}
+
+// Workspace returns the Workspace found when the CLI started, or nil if none.
+func (s *Session) Workspace() *Workspace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.workspace
+}
+
+// detectWorkspace runs the WorkspaceDetectors on the working directory of the
+// Session, once.
+func (c *GomCLI) detectWorkspace() {
+	if c.detected {
+		return
+	}
+	c.detected = true
+
+	for _, detect := range c.detectors {
+		ws := detect(c.session.Dir())
+		if ws == nil {
+			continue
+		}
+
+		for name, value := range ws.Vars {
+			c.session.SetVar(name, value)
+		}
+		c.session.SetVar("workspace", ws.Name)
+		c.session.SetVar("workspace_root", ws.Root)
+
+		c.session.mu.Lock()
+		c.session.workspace = ws
+		c.session.mu.Unlock()
+
+		if len(ws.Commands) > 0 || ws.Prompt != "" {
+			c.PushContext(ws.Name, ws.Commands, ws.Prompt)
+			c.contexts[len(c.contexts)-1].onPop = func() {
+				c.closeWorkspace(ws)
+			}
+		}
+		return
+	}
+}
+
+// closeWorkspace unsets the session variables set when ws was detected.
+func (c *GomCLI) closeWorkspace(ws *Workspace) {
+	for name := range ws.Vars {
+		c.session.UnsetVar(name)
+	}
+	c.session.UnsetVar("workspace")
+	c.session.UnsetVar("workspace_root")
+
+	c.session.mu.Lock()
+	c.session.workspace = nil
+	c.session.mu.Unlock()
+}
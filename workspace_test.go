@@ -0,0 +1,55 @@
+package gomcli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorkspacePushesContext(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "project.toml"), nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewWithIO(&bytes.Buffer{}, &bytes.Buffer{})
+	c.EnableBuiltins()
+	if err := c.Session().Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	built := false
+	detect := FileDetector("demo", "project.toml")
+	c.AddWorkspaceDetector(func(dir string) *Workspace {
+		ws := detect(dir)
+		if ws != nil {
+			ws.Prompt = "demo> "
+			ws.Commands = []Command{{Name: "build", Function: func() { built = true }}}
+		}
+		return ws
+	})
+
+	c.detectWorkspace()
+	if got := c.Context(); !reflect.DeepEqual(got, []string{"demo"}) {
+		t.Fatalf("Context() = %q, want [demo]", got)
+	}
+	if c.currentPrompt() != "demo> " {
+		t.Errorf("prompt = %q, want %q", c.currentPrompt(), "demo> ")
+	}
+	c.processInput("build")
+	if !built {
+		t.Error("build did not run")
+	}
+
+	c.processInput("exit")
+	if c.Context() != nil || c.Session().Workspace() != nil {
+		t.Errorf("workspace still open: %q, %v", c.Context(), c.Session().Workspace())
+	}
+	if _, ok := c.Session().Var("workspace"); ok {
+		t.Error("workspace variable still set")
+	}
+	if c.stopRequested() {
+		t.Error("exit stopped the CLI instead of closing the workspace")
+	}
+}